@@ -0,0 +1,55 @@
+package nacos
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestServiceClientClose(t *testing.T) {
+	var deregistered int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/nacos/v1/ns/instance":
+			if r.Method == http.MethodDelete {
+				atomic.AddInt32(&deregistered, 1)
+			}
+			_, _ = w.Write([]byte("ok"))
+		case "/nacos/v1/ns/instance/beat":
+			_, _ = w.Write([]byte(`{"clientBeatInterval":50,"code":10200}`))
+		case "/nacos/v1/cs/configs/listener":
+			_ = r.ParseForm()
+			<-r.Context().Done()
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	a, err := NewServiceClient(srv.URL+"/nacos", DiscoveryIP("127.0.0.1"), LogLevel("error"))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	_, err = a.RegisterInstance("", 8000, "my_test_service", ParamClusterName("aa"))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	ch := a.ListenConfig("testDataId", "group", func(string) {})
+	<-time.After(200 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err = a.Close(ctx); err != nil {
+		t.Error(err)
+		return
+	}
+	if atomic.LoadInt32(&deregistered) != 1 {
+		t.Error("expected instance to be deregistered on close")
+	}
+	if _, ok := <-ch; ok {
+		t.Error("expected listen channel to be closed")
+	}
+}
@@ -1,13 +1,19 @@
 package nacos
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
-	"strings"
 
 	"github.com/magicdvd/nacos-client/constant"
 )
 
 func (c *ServiceClient) PublishConfig(dataID string, group string, content string, params ...Param) error {
+	return c.PublishConfigWithContext(context.Background(), dataID, group, content, params...)
+}
+
+func (c *ServiceClient) PublishConfigWithContext(ctx context.Context, dataID string, group string, content string, params ...Param) error {
 	query := newParamMap()
 	query.Set(
 		paramConfigDataID(dataID),
@@ -16,7 +22,8 @@ func (c *ServiceClient) PublishConfig(dataID string, group string, content strin
 		ParamConfigTenant(c.opts.defaultTenant),
 	)
 	query.Set(params...)
-	_, err := c.client.api(http.MethodPost, constant.APIConfig, nil, query)
+	ctx = query.context(ctx)
+	err := c.transport.PublishConfig(ctx, newConfigRequest(query))
 	if err != nil {
 		c.log.Error("PublishConfig", "api", err)
 		return err
@@ -24,7 +31,121 @@ func (c *ServiceClient) PublishConfig(dataID string, group string, content strin
 	return nil
 }
 
+func (c *ServiceClient) PublishConfigCAS(dataID string, group string, content string, expectedMD5 string, params ...Param) error {
+	return c.PublishConfigCASWithContext(context.Background(), dataID, group, content, expectedMD5, params...)
+}
+
+//PublishConfigCASWithContext 服务端配置的md5与 expectedMD5 一致时才发布, 否则返回 ErrConfigConflict(需要Nacos 2.x)
+//Nacos 1.x 会忽略casMd5直接覆盖, 通过http连接版本低于2.0的服务端时返回 ErrNotSupported, 不会发布
+//与Nacos一样, 配置不存在时直接新增; expectedMD5 为空表示期望配置不存在, 已存在时返回 ErrConfigConflict
+//新增不是原子的, 见 publishCAS
+func (c *ServiceClient) PublishConfigCASWithContext(ctx context.Context, dataID string, group string, content string, expectedMD5 string, params ...Param) error {
+	query := newParamMap()
+	query.Set(
+		paramConfigDataID(dataID),
+		paramConfigGroup(group),
+		paramConfigContent(content),
+		ParamConfigTenant(c.opts.defaultTenant),
+	)
+	query.Set(params...)
+	ctx = query.context(ctx)
+	req := newConfigRequest(query)
+	req.CasMD5 = expectedMD5
+	if expectedMD5 == "" {
+		_, err := c.transport.GetConfig(ctx, req)
+		if err == nil {
+			err = configCreateConflict()
+		}
+		if !errors.Is(err, ErrConfigNotFound) {
+			c.log.Error("PublishConfigCAS", "api", err)
+			return err
+		}
+	}
+	if err := c.publishCAS(ctx, req); err != nil {
+		c.log.Error("PublishConfigCAS", "api", err)
+		return err
+	}
+	return nil
+}
+
+//configCreateConflict 期望配置不存在时, 配置已存在或被其他人同时新增
+func configCreateConflict() error {
+	return &StatusError{Code: http.StatusConflict, Endpoint: constant.APIConfig, Body: "config has been created by others"}
+}
+
+//publishCAS 以 req.CasMD5 做 compare-and-swap 发布; CasMD5 为空时新增配置
+//Nacos 没有只新增的接口, 新增后重新读取, 内容被其他人覆盖时返回 ErrConfigConflict;
+//同时新增相同内容时无法区分, 都会成功, 因此新增不是原子的
+func (c *ServiceClient) publishCAS(ctx context.Context, req *ConfigRequest) error {
+	if err := c.transport.PublishConfig(ctx, req); err != nil || req.CasMD5 != "" {
+		return err
+	}
+	current, err := c.transport.GetConfig(ctx, req)
+	if err != nil && !errors.Is(err, ErrConfigNotFound) {
+		return err
+	}
+	if err != nil || current != req.Content {
+		return configCreateConflict()
+	}
+	return nil
+}
+
+func (c *ServiceClient) UpdateConfig(dataID string, group string, update func(current string) (string, error), params ...Param) (string, error) {
+	return c.UpdateConfigWithContext(context.Background(), dataID, group, update, params...)
+}
+
+//UpdateConfigWithContext 读取服务端的配置(不存在时为空), 通过 update 修改后以 compare-and-swap 发布, 返回发布的内容
+//发布冲突时重新读取并调用 update, 最多尝试 constant.UpdateConfigMaxAttempts 次, 仍然冲突时返回 ErrConfigConflict;
+//update 返回错误时不发布并直接返回该错误, 内容没有变化时不发布
+//配置不存在时的新增不是原子的: 新增后内容被其他人修改时当作冲突重试, 同时新增相同的内容时都会成功
+//修改已存在的配置需要 compare-and-swap, Nacos 1.x 上返回 ErrNotSupported
+func (c *ServiceClient) UpdateConfigWithContext(ctx context.Context, dataID string, group string, update func(current string) (string, error), params ...Param) (string, error) {
+	query := newParamMap()
+	query.Set(
+		paramConfigDataID(dataID),
+		paramConfigGroup(group),
+		ParamConfigTenant(c.opts.defaultTenant),
+	)
+	query.Set(params...)
+	ctx = query.context(ctx)
+	var err error
+	for i := 0; i < constant.UpdateConfigMaxAttempts; i++ {
+		req := newConfigRequest(query)
+		//不使用本地快照, 必须基于服务端当前的内容修改
+		current, getErr := c.transport.GetConfig(ctx, req)
+		if getErr != nil && !errors.Is(getErr, ErrConfigNotFound) {
+			c.log.Error("UpdateConfig", "api", getErr)
+			return "", getErr
+		}
+		content, updateErr := update(current)
+		if updateErr != nil {
+			return "", updateErr
+		}
+		if getErr == nil {
+			if content == current {
+				return content, nil
+			}
+			req.CasMD5 = md5string(current)
+		}
+		req.Content = content
+		if err = c.publishCAS(ctx, req); err == nil {
+			return content, nil
+		}
+		if !errors.Is(err, ErrConfigConflict) {
+			c.log.Error("UpdateConfig", "api", err)
+			return "", err
+		}
+		c.log.Debug(fmt.Sprintf("update config conflict dataId: %s, group: %s, tenant: %s, attempt: %d", dataID, group, query.tenant, i+1))
+	}
+	c.log.Error("UpdateConfig", "api", err)
+	return "", err
+}
+
 func (c *ServiceClient) GetConfig(dataID string, group string, params ...Param) (string, error) {
+	return c.GetConfigWithContext(context.Background(), dataID, group, params...)
+}
+
+func (c *ServiceClient) GetConfigWithContext(ctx context.Context, dataID string, group string, params ...Param) (string, error) {
 	query := newParamMap()
 	query.Set(
 		paramConfigDataID(dataID),
@@ -32,15 +153,36 @@ func (c *ServiceClient) GetConfig(dataID string, group string, params ...Param)
 		ParamConfigTenant(c.opts.defaultTenant),
 	)
 	query.Set(params...)
-	b, err := c.client.api(http.MethodGet, constant.APIConfig, query, nil)
+	ctx = query.context(ctx)
+	if content, ok := c.configCache.failover(query.tenant, query.group, query.dataID); ok {
+		c.log.Warn("GetConfig", "use failover config", dataID, group, query.tenant)
+		return content, nil
+	}
+	content, err := c.transport.GetConfig(ctx, newConfigRequest(query))
 	if err != nil {
 		c.log.Error("GetConfig", "api", err)
+		if isNotFoundError(err) {
+			c.configCache.removeSnapshot(query.tenant, query.group, query.dataID)
+			return "", err
+		}
+		//服务端不可用时使用本地快照
+		if ctx.Err() == nil {
+			if content, ok := c.configCache.snapshot(query.tenant, query.group, query.dataID); ok {
+				c.log.Warn("GetConfig", "use snapshot config", dataID, group, query.tenant)
+				return content, nil
+			}
+		}
 		return "", err
 	}
-	return string(b), nil
+	c.configCache.saveSnapshot(query.tenant, query.group, query.dataID, content)
+	return content, nil
 }
 
 func (c *ServiceClient) RemoveConfig(dataID string, group string, params ...Param) error {
+	return c.RemoveConfigWithContext(context.Background(), dataID, group, params...)
+}
+
+func (c *ServiceClient) RemoveConfigWithContext(ctx context.Context, dataID string, group string, params ...Param) error {
 	query := newParamMap()
 	query.Set(
 		paramConfigDataID(dataID),
@@ -48,45 +190,56 @@ func (c *ServiceClient) RemoveConfig(dataID string, group string, params ...Para
 		ParamConfigTenant(c.opts.defaultTenant),
 	)
 	query.Set(params...)
-	_, err := c.client.api(http.MethodDelete, constant.APIConfig, query, nil)
+	ctx = query.context(ctx)
+	err := c.transport.RemoveConfig(ctx, newConfigRequest(query))
 	if err != nil {
 		c.log.Error("RemoveConfig", "api", err)
 		return err
 	}
+	c.configCache.removeSnapshot(query.tenant, query.group, query.dataID)
 	return nil
 }
 
 func (c *ServiceClient) ListenConfig(dataID string, group string, callback func(string), params ...Param) <-chan error {
+	return c.ListenConfigWithContext(context.Background(), dataID, group, callback, params...)
+}
+
+//ListenConfigWithContext ctx 结束或客户端关闭时停止监听并关闭返回的channel
+//所有监听的配置共享长轮询请求; 出错时自动退避重试, 错误(*ConfigListenError)发送到返回的channel但不会停止监听,
+//没有及时读取的错误会被丢弃; callback 在该监听自己的goroutine中按顺序调用, 回调较慢时只回调最新的内容
+func (c *ServiceClient) ListenConfigWithContext(ctx context.Context, dataID string, group string, callback func(string), params ...Param) <-chan error {
 	ch := make(chan error)
 	query := newParamMap()
-	query.Set(
-		paramConfigDataID(dataID),
-		paramConfigGroup(group),
-		ParamConfigTenant(c.opts.defaultTenant),
-	)
-	query.ListenConfigs("")
-	go func() {
+	query.Set(ParamConfigTenant(c.opts.defaultTenant))
+	query.Set(params...)
+	ctx = query.context(ctx)
+	w := newConfigWatcher(callback)
+	c.configListener.add(dataID, group, query.tenant, params, w)
+	c.goFunc(func() {
+		ctx, cancel := c.withClientContext(ctx)
+		defer cancel()
 		defer close(ch)
+		defer c.configListener.remove(dataID, group, query.tenant, w)
 		for {
-			b, err := c.client.listen(http.MethodPost, constant.APIConfigListen, c.opts.listenInterval, nil, query)
-			if err != nil {
-				c.log.Error("ListenConfig", "api", err)
-				ch <- err
-				return
-			}
-			nc := string(b)
-			if strings.ToLower(strings.Trim(nc, " ")) == "" {
-				continue
-			}
-			nb, err := c.GetConfig(dataID, group, params...)
-			if err != nil {
-				c.log.Error("ListenConfig", "api", err)
-				ch <- err
+			select {
+			case <-ctx.Done():
 				return
+			case content := <-w.updates:
+				callback(content)
+			case err := <-w.errCh:
+				//等待读取错误时继续回调, 回调不依赖调用方读取错误
+				for sent := false; !sent; {
+					select {
+					case ch <- err:
+						sent = true
+					case content := <-w.updates:
+						callback(content)
+					case <-ctx.Done():
+						return
+					}
+				}
 			}
-			callback(nb)
-			query.ListenConfigs(nb)
 		}
-	}()
+	})
 	return ch
 }
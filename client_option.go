@@ -6,15 +6,24 @@ import (
 )
 
 type clientOptions struct {
-	maxCacheTime      time.Duration
-	log               LogInterface
-	httpClient        *httpClient
-	listenInterval    time.Duration
-	defautNameSpaceID string
-	defaultTenant     string
-	discoveryIP       string
-	appName           string
-	maxRetryTimes     int
+	maxCacheTime        time.Duration
+	log                 LogInterface
+	httpClient          *httpClient
+	listenInterval      time.Duration
+	defautNameSpaceID   string
+	defaultTenant       string
+	discoveryIP         string
+	appName             string
+	maxRetryTimes       int
+	endpointInterval    time.Duration
+	configCacheDir      string
+	configFailoverDir   string
+	namingCacheDir      string
+	namingCacheMaxStale time.Duration
+	selector            InstanceSelector
+	enableGRPC          bool
+	grpcPortOffset      int
+	transport           Transport
 }
 
 type ClientOption interface {
@@ -122,3 +131,87 @@ func MaxHeartBeatRetryTimes(c int) ClientOption {
 		o.maxRetryTimes = c
 	})
 }
+
+func ServerBlacklistTime(s time.Duration) ClientOption {
+	return newFuncClientOption(func(o *clientOptions) {
+		o.httpClient.servers.blacklistTime = s
+	})
+}
+
+//Endpoint 地址服务器, 定期从中获取nacos节点列表
+func Endpoint(s string) ClientOption {
+	return newFuncClientOption(func(o *clientOptions) {
+		o.httpClient.endpoint = s
+	})
+}
+
+func EndpointRefreshInterval(s time.Duration) ClientOption {
+	return newFuncClientOption(func(o *clientOptions) {
+		o.endpointInterval = s
+	})
+}
+
+//ConfigCacheDir 配置快照目录, 每次成功获取的配置都会保存, 服务端不可用时使用
+func ConfigCacheDir(s string) ClientOption {
+	return newFuncClientOption(func(o *clientOptions) {
+		o.configCacheDir = s
+	})
+}
+
+//ConfigFailoverDir 配置故障转移目录, 其中存在的配置优先于服务端的值
+func ConfigFailoverDir(s string) ClientOption {
+	return newFuncClientOption(func(o *clientOptions) {
+		o.configFailoverDir = s
+	})
+}
+
+//NamingCacheDir 服务实例快照目录, 启动时加载, 获取实例列表失败时使用
+func NamingCacheDir(s string) ClientOption {
+	return newFuncClientOption(func(o *clientOptions) {
+		o.namingCacheDir = s
+	})
+}
+
+//NamingCacheMaxStale 服务实例快照允许使用的最长过期时间, 0表示不限制
+func NamingCacheMaxStale(s time.Duration) ClientOption {
+	return newFuncClientOption(func(o *clientOptions) {
+		o.namingCacheMaxStale = s
+	})
+}
+
+//Selector SelectOneHealthyInstance 使用的实例选择策略
+func Selector(s InstanceSelector) ClientOption {
+	return newFuncClientOption(func(o *clientOptions) {
+		if s != nil {
+			o.selector = s
+		}
+	})
+}
+
+//EnableGRPC 使用Nacos 2.x的gRPC协议, 连接失败时回退到http
+func EnableGRPC(b bool) ClientOption {
+	return newFuncClientOption(func(o *clientOptions) {
+		o.enableGRPC = b
+	})
+}
+
+//GRPCPortOffset gRPC端口相对http端口的偏移, 默认1000
+func GRPCPortOffset(n int) ClientOption {
+	return newFuncClientOption(func(o *clientOptions) {
+		o.grpcPortOffset = n
+	})
+}
+
+//WithTransport 使用自定义的 Transport, 设置后忽略服务端地址和 EnableGRPC, 例如测试时使用 NewFakeTransport
+func WithTransport(t Transport) ClientOption {
+	return newFuncClientOption(func(o *clientOptions) {
+		o.transport = t
+	})
+}
+
+//WithRetryPolicy 所有http请求的重试策略, 默认 DefaultRetryPolicy(), NoRetry() 关闭重试
+func WithRetryPolicy(p RetryPolicy) ClientOption {
+	return newFuncClientOption(func(o *clientOptions) {
+		o.httpClient.retryPolicy = &p
+	})
+}
@@ -12,7 +12,7 @@ func TestNewServiceClient(t *testing.T) {
 		t.Error(err)
 		return
 	}
-	err = a.RegisterInstance("127.0.0.1", 8000, "my_test_service")
+	_, err = a.RegisterInstance("127.0.0.1", 8000, "my_test_service")
 	if err != nil {
 		t.Error(err)
 		return
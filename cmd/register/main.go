@@ -12,12 +12,12 @@ func main() {
 		fmt.Println(err)
 		return
 	}
-	err = a.RegisterInstance("", 8000, "my_test_service", nacos.ParamClusterName("aa"))
+	aa, err := a.RegisterInstance("", 8000, "my_test_service", nacos.ParamClusterName("aa"))
 	if err != nil {
 		fmt.Println(err)
 		return
 	}
-	err = a.RegisterInstance("", 8000, "my_test_service", nacos.ParamClusterName("bb"))
+	_, err = a.RegisterInstance("", 8000, "my_test_service", nacos.ParamClusterName("bb"))
 	if err != nil {
 		fmt.Println(err)
 		return
@@ -28,11 +28,12 @@ func main() {
 	// 	return
 	// }
 	// <-time.After(30 * time.Second)
-	// err = a.DeregisterInstance("172.21.0.1", 8000, "my_test_service", nacos.ParamClusterName("aa"))
+	// err = aa.Deregister()
 	// if err != nil {
 	// 	fmt.Println(err)
 	// 	return
 	// }
-	ch := make(chan bool)
-	<-ch
+	for err := range a.HeartBeatErr() {
+		fmt.Println(err, aa.Status())
+	}
 }
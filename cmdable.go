@@ -1,24 +1,138 @@
 package nacos
 
+import "context"
+
 type ServiceCmdable interface {
 	//RegisterInstance 注册实例
-	RegisterInstance(ip string, port uint, serviceName string, params ...Param) error
-	//HeartBeatErr 注册实例如果出错时候的回调
+	RegisterInstance(ip string, port uint, serviceName string, params ...Param) (*Registration, error)
+	//RegisterInstanceWithContext 注册实例
+	RegisterInstanceWithContext(ctx context.Context, ip string, port uint, serviceName string, params ...Param) (*Registration, error)
+	//HeartBeatErr 所有实例的心跳错误, 不会阻塞心跳, Close 后关闭
 	HeartBeatErr() <-chan error
+	//Heartbeat 已注册临时实例的心跳状态和事件
+	Heartbeat(ip string, port uint, serviceName string, params ...Param) (*Heartbeat, bool)
 	//DeregisterInstance 销毁实例
 	DeregisterInstance(ip string, port uint, serviceName string, params ...Param) error
+	//DeregisterInstanceWithContext 销毁实例
+	DeregisterInstanceWithContext(ctx context.Context, ip string, port uint, serviceName string, params ...Param) error
+	//UpdateInstance 修改实例的权重/metadata/上下线状态
+	UpdateInstance(ip string, port uint, serviceName string, params ...Param) error
+	//UpdateInstanceWithContext 修改实例的权重/metadata/上下线状态
+	UpdateInstanceWithContext(ctx context.Context, ip string, port uint, serviceName string, params ...Param) error
+	//ListPersistentInstances 获取本机IP上的持久化实例
+	ListPersistentInstances(serviceName string, params ...Param) ([]*Instance, error)
+	//ListPersistentInstancesWithContext 获取本机IP上的持久化实例
+	ListPersistentInstancesWithContext(ctx context.Context, serviceName string, params ...Param) ([]*Instance, error)
+	//ReconcilePersistentInstances 注销本机IP上 AppName 相同但没有被本客户端注册的持久化实例
+	ReconcilePersistentInstances(serviceName string, params ...Param) ([]*Instance, error)
+	//ReconcilePersistentInstancesWithContext 注销本机IP上 AppName 相同但没有被本客户端注册的持久化实例
+	ReconcilePersistentInstancesWithContext(ctx context.Context, serviceName string, params ...Param) ([]*Instance, error)
 	//GetService 获取服务
 	GetService(serviceName string, lazy bool, params ...Param) (*Service, error)
+	//GetServiceWithContext 获取服务
+	GetServiceWithContext(ctx context.Context, serviceName string, lazy bool, params ...Param) (*Service, error)
+	//ListServices 分页获取服务名
+	ListServices(pageNo int, pageSize int, params ...Param) (*ServiceList, error)
+	//ListServicesWithContext 分页获取服务名
+	ListServicesWithContext(ctx context.Context, pageNo int, pageSize int, params ...Param) (*ServiceList, error)
+	//GetServiceDetail 获取服务详情
+	GetServiceDetail(serviceName string, params ...Param) (*ServiceDetail, error)
+	//GetServiceDetailWithContext 获取服务详情
+	GetServiceDetailWithContext(ctx context.Context, serviceName string, params ...Param) (*ServiceDetail, error)
+	//CreateService 创建服务
+	CreateService(serviceName string, def ServiceDefinition, params ...Param) error
+	//CreateServiceWithContext 创建服务
+	CreateServiceWithContext(ctx context.Context, serviceName string, def ServiceDefinition, params ...Param) error
+	//UpdateService 修改服务
+	UpdateService(serviceName string, def ServiceDefinition, params ...Param) error
+	//UpdateServiceWithContext 修改服务
+	UpdateServiceWithContext(ctx context.Context, serviceName string, def ServiceDefinition, params ...Param) error
+	//DeleteService 删除服务
+	DeleteService(serviceName string, params ...Param) error
+	//DeleteServiceWithContext 删除服务
+	DeleteServiceWithContext(ctx context.Context, serviceName string, params ...Param) error
+	//GetInstanceDetail 获取实例详情
+	GetInstanceDetail(ip string, port uint, serviceName string, params ...Param) (*InstanceDetail, error)
+	//GetInstanceDetailWithContext 获取实例详情
+	GetInstanceDetailWithContext(ctx context.Context, ip string, port uint, serviceName string, params ...Param) (*InstanceDetail, error)
+	//SelectInstances 获取健康状态为healthy, 已启用且权重大于0的实例
+	SelectInstances(serviceName string, healthy bool, params ...Param) ([]*Instance, error)
+	//SelectInstancesWithContext 获取健康状态为healthy, 已启用且权重大于0的实例
+	SelectInstancesWithContext(ctx context.Context, serviceName string, healthy bool, params ...Param) ([]*Instance, error)
+	//SelectOneHealthyInstance 按选择策略获取一个健康实例, 优先同集群
+	SelectOneHealthyInstance(serviceName string, params ...Param) (*Instance, error)
+	//SelectOneHealthyInstanceWithContext 按选择策略获取一个健康实例, 优先同集群
+	SelectOneHealthyInstanceWithContext(ctx context.Context, serviceName string, params ...Param) (*Instance, error)
 	//Subscribe 订阅
 	Subscribe(serviceName string, callback func(*Service), params ...Param) error
+	//SubscribeWithContext 订阅
+	SubscribeWithContext(ctx context.Context, serviceName string, callback func(*Service), params ...Param) error
 	//Unsubscribe 取消订阅
 	Unsubscribe(serviceName string, params ...Param)
+	//ListNamespaces 获取所有命名空间
+	ListNamespaces(params ...Param) ([]*Namespace, error)
+	//ListNamespacesWithContext 获取所有命名空间
+	ListNamespacesWithContext(ctx context.Context, params ...Param) ([]*Namespace, error)
+	//CreateNamespace 创建命名空间
+	CreateNamespace(namespaceID string, name string, desc string, params ...Param) error
+	//CreateNamespaceWithContext 创建命名空间
+	CreateNamespaceWithContext(ctx context.Context, namespaceID string, name string, desc string, params ...Param) error
+	//UpdateNamespace 修改命名空间
+	UpdateNamespace(namespaceID string, name string, desc string, params ...Param) error
+	//UpdateNamespaceWithContext 修改命名空间
+	UpdateNamespaceWithContext(ctx context.Context, namespaceID string, name string, desc string, params ...Param) error
+	//DeleteNamespace 删除命名空间
+	DeleteNamespace(namespaceID string, params ...Param) error
+	//DeleteNamespaceWithContext 删除命名空间
+	DeleteNamespaceWithContext(ctx context.Context, namespaceID string, params ...Param) error
 	//PublishConfig 发布配置
 	PublishConfig(dataID string, group string, content string, params ...Param) error
+	//PublishConfigWithContext 发布配置
+	PublishConfigWithContext(ctx context.Context, dataID string, group string, content string, params ...Param) error
+	//PublishConfigCAS md5与期望一致时发布配置, 否则返回 ErrConfigConflict
+	PublishConfigCAS(dataID string, group string, content string, expectedMD5 string, params ...Param) error
+	//PublishConfigCASWithContext md5与期望一致时发布配置
+	PublishConfigCASWithContext(ctx context.Context, dataID string, group string, content string, expectedMD5 string, params ...Param) error
+	//UpdateConfig 读取-修改-发布配置, 冲突时重试
+	UpdateConfig(dataID string, group string, update func(current string) (string, error), params ...Param) (string, error)
+	//UpdateConfigWithContext 读取-修改-发布配置, 冲突时重试
+	UpdateConfigWithContext(ctx context.Context, dataID string, group string, update func(current string) (string, error), params ...Param) (string, error)
 	//GetConfig 获取配置
 	GetConfig(dataID string, group string, params ...Param) (string, error)
+	//GetConfigWithContext 获取配置
+	GetConfigWithContext(ctx context.Context, dataID string, group string, params ...Param) (string, error)
 	//RemoveConfig 获取配置
 	RemoveConfig(dataID string, group string, params ...Param) error
+	//RemoveConfigWithContext 删除配置
+	RemoveConfigWithContext(ctx context.Context, dataID string, group string, params ...Param) error
+	//ListConfigHistory 分页获取配置的历史记录, 按时间倒序, 不包含内容
+	ListConfigHistory(dataID string, group string, pageNo int, pageSize int, params ...Param) (*ConfigHistoryPage, error)
+	//ListConfigHistoryWithContext 分页获取配置的历史记录
+	ListConfigHistoryWithContext(ctx context.Context, dataID string, group string, pageNo int, pageSize int, params ...Param) (*ConfigHistoryPage, error)
+	//GetConfigHistory 获取历史记录详情
+	GetConfigHistory(dataID string, group string, nid int64, params ...Param) (*ConfigHistory, error)
+	//GetConfigHistoryWithContext 获取历史记录详情
+	GetConfigHistoryWithContext(ctx context.Context, dataID string, group string, nid int64, params ...Param) (*ConfigHistory, error)
+	//GetPreviousConfig 获取最近一次变更前的配置
+	GetPreviousConfig(dataID string, group string, params ...Param) (*ConfigHistory, error)
+	//GetPreviousConfigWithContext 获取最近一次变更前的配置
+	GetPreviousConfigWithContext(ctx context.Context, dataID string, group string, params ...Param) (*ConfigHistory, error)
+	//RollbackConfig 回滚到历史版本
+	RollbackConfig(dataID string, group string, nid int64, params ...Param) error
+	//RollbackConfigWithContext 回滚到历史版本
+	RollbackConfigWithContext(ctx context.Context, dataID string, group string, nid int64, params ...Param) error
 	//ListenConfig 监听配置
 	ListenConfig(dataID string, group string, callback func(string), params ...Param) <-chan error
+	//ListenConfigWithContext 监听配置, ctx 结束时停止监听
+	ListenConfigWithContext(ctx context.Context, dataID string, group string, callback func(string), params ...Param) <-chan error
+	//ListenConfigChange 监听配置, 回调包含变更前后的内容和key级别的差异
+	ListenConfigChange(dataID string, group string, callback func(*ConfigChangeEvent), params ...Param) <-chan error
+	//ListenConfigChangeWithContext 监听配置变更事件, ctx 结束时停止监听
+	ListenConfigChangeWithContext(ctx context.Context, dataID string, group string, callback func(*ConfigChangeEvent), params ...Param) <-chan error
+	//BindConfig 获取配置并解析到结构体, 配置变更时原子替换
+	BindConfig(dataID string, group string, v interface{}, validate func(interface{}) error, params ...Param) (*ConfigBinding, error)
+	//BindConfigWithContext 获取配置并解析到结构体, ctx 结束时停止监听
+	BindConfigWithContext(ctx context.Context, dataID string, group string, v interface{}, validate func(interface{}) error, params ...Param) (*ConfigBinding, error)
+	//Close 注销临时实例并停止所有后台goroutine
+	Close(ctx context.Context) error
 }
@@ -0,0 +1,102 @@
+package nacos
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+//ConfigBinding 绑定到结构体的配置, 每次配置变更时解析为新的值并原子替换
+type ConfigBinding struct {
+	value    atomic.Value
+	typ      reflect.Type
+	format   string
+	validate func(interface{}) error
+	lock     sync.Mutex
+	lastMD5  string
+	errCh    chan error
+}
+
+//Load 返回当前配置, 类型与 BindConfig 传入的指针相同
+func (b *ConfigBinding) Load() interface{} {
+	return b.value.Load()
+}
+
+//Err 监听出错, 解析失败或校验未通过的配置, 出错时保留上一次的值
+func (b *ConfigBinding) Err() <-chan error {
+	return b.errCh
+}
+
+func (b *ConfigBinding) apply(content string, v interface{}) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	m := md5string(content)
+	if m == b.lastMD5 {
+		return nil
+	}
+	if v == nil {
+		v = reflect.New(b.typ).Interface()
+	}
+	if err := decodeConfig(b.format, content, v); err != nil {
+		return err
+	}
+	if b.validate != nil {
+		if err := b.validate(v); err != nil {
+			return err
+		}
+	}
+	b.value.Store(v)
+	b.lastMD5 = m
+	return nil
+}
+
+func (b *ConfigBinding) report(err error) {
+	select {
+	case b.errCh <- err:
+	default:
+	}
+}
+
+func (c *ServiceClient) BindConfig(dataID string, group string, v interface{}, validate func(interface{}) error, params ...Param) (*ConfigBinding, error) {
+	return c.BindConfigWithContext(context.Background(), dataID, group, v, validate, params...)
+}
+
+//BindConfigWithContext 获取配置并解析到 v (结构体指针), 之后监听变更并原子替换
+//格式由 ParamConfigType 指定, 未指定时根据dataId扩展名判断, 支持 json/yaml/toml/properties
+//validate 返回错误时拒绝该配置, ctx 结束时停止监听
+func (c *ServiceClient) BindConfigWithContext(ctx context.Context, dataID string, group string, v interface{}, validate func(interface{}) error, params ...Param) (*ConfigBinding, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return nil, errors.New("BindConfig target must be a non-nil pointer")
+	}
+	query := newParamMap()
+	query.Set(params...)
+	b := &ConfigBinding{
+		typ:      rv.Elem().Type(),
+		format:   configFormat(query.tp, dataID),
+		validate: validate,
+		errCh:    make(chan error, 1),
+	}
+	content, err := c.GetConfigWithContext(ctx, dataID, group, params...)
+	if err != nil {
+		return nil, err
+	}
+	if err = b.apply(content, v); err != nil {
+		c.log.Error("BindConfig", dataID, group, err)
+		return nil, err
+	}
+	ch := c.ListenConfigWithContext(ctx, dataID, group, func(s string) {
+		if err := b.apply(s, nil); err != nil {
+			c.log.Error("BindConfig", dataID, group, err)
+			b.report(err)
+		}
+	}, params...)
+	c.goFunc(func() {
+		for err := range ch {
+			b.report(err)
+		}
+	})
+	return b, nil
+}
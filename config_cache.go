@@ -0,0 +1,95 @@
+package nacos
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+//configCache 本地配置快照和故障转移目录
+//
+//目录结构与官方sdk一致:
+//
+//	无tenant: {dir}/config-data/{group}/{dataId}
+//	有tenant: {dir}/config-data-tenant/{tenant}/{group}/{dataId}
+type configCache struct {
+	snapshotDir string
+	failoverDir string
+	log         LogInterface
+}
+
+func (c *configCache) path(dir, tenant, group, dataID string) string {
+	if tenant == "" {
+		return filepath.Join(dir, "config-data", url.PathEscape(group), url.PathEscape(dataID))
+	}
+	return filepath.Join(dir, "config-data-tenant", url.PathEscape(tenant), url.PathEscape(group), url.PathEscape(dataID))
+}
+
+//failover 读取故障转移目录中的配置, 存在时优先于服务端的值
+func (c *configCache) failover(tenant, group, dataID string) (string, bool) {
+	if c.failoverDir == "" {
+		return "", false
+	}
+	b, err := ioutil.ReadFile(c.path(c.failoverDir, tenant, group, dataID))
+	if err != nil {
+		return "", false
+	}
+	return string(b), true
+}
+
+//snapshot 读取最近一次从服务端成功获取的配置
+func (c *configCache) snapshot(tenant, group, dataID string) (string, bool) {
+	if c.snapshotDir == "" {
+		return "", false
+	}
+	b, err := ioutil.ReadFile(c.path(c.snapshotDir, tenant, group, dataID))
+	if err != nil {
+		return "", false
+	}
+	return string(b), true
+}
+
+func (c *configCache) saveSnapshot(tenant, group, dataID, content string) {
+	if c.snapshotDir == "" {
+		return
+	}
+	if err := writeFileAtomic(c.path(c.snapshotDir, tenant, group, dataID), []byte(content)); err != nil {
+		c.log.Error("saveSnapshot", dataID, group, tenant, err)
+	}
+}
+
+func (c *configCache) removeSnapshot(tenant, group, dataID string) {
+	if c.snapshotDir == "" {
+		return
+	}
+	err := os.Remove(c.path(c.snapshotDir, tenant, group, dataID))
+	if err != nil && !os.IsNotExist(err) {
+		c.log.Error("removeSnapshot", dataID, group, tenant, err)
+	}
+}
+
+//writeFileAtomic 先写临时文件再rename, 避免进程中断时留下不完整的文件
+func writeFileAtomic(name string, b []byte) error {
+	dir := filepath.Dir(name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(dir, ".tmp-"+filepath.Base(name))
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(b)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if err = os.Rename(f.Name(), name); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
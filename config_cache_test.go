@@ -0,0 +1,125 @@
+package nacos
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetConfigSnapshotAndFailover(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nacos-config-cache")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	var down int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&down) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("server-value"))
+	}))
+	defer srv.Close()
+	a, err := NewServiceClient(srv.URL+"/nacos", DiscoveryIP("127.0.0.1"), LogLevel("error"),
+		ConfigCacheDir(dir+"/snapshot"), ConfigFailoverDir(dir+"/failover"))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	v, err := a.GetConfig("testDataId", "group", ParamConfigTenant("dev"))
+	if err != nil || v != "server-value" {
+		t.Error("unexpected config", v, err)
+		return
+	}
+	atomic.StoreInt32(&down, 1)
+	v, err = a.GetConfig("testDataId", "group", ParamConfigTenant("dev"))
+	if err != nil || v != "server-value" {
+		t.Error("expected snapshot value", v, err)
+		return
+	}
+	if _, err = a.GetConfig("otherDataId", "group"); err == nil {
+		t.Error("expected error without snapshot")
+	}
+	err = writeFileAtomic(dir+"/failover/config-data-tenant/dev/group/testDataId", []byte("failover-value"))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	v, err = a.GetConfig("testDataId", "group", ParamConfigTenant("dev"))
+	if err != nil || v != "failover-value" {
+		t.Error("expected failover value", v, err)
+	}
+}
+
+func TestListenConfigOffline(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nacos-config-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err = writeFileAtomic(dir+"/snapshot/config-data/group/snapshotDataId", []byte("snapshot-value")); err != nil {
+		t.Fatal(err)
+	}
+	if err = writeFileAtomic(dir+"/failover/config-data/group/failoverDataId", []byte("failover-value")); err != nil {
+		t.Fatal(err)
+	}
+	fake := NewFakeTransport()
+	a, err := NewServiceClient("", WithTransport(fake), LogLevel("error"),
+		ConfigCacheDir(dir+"/snapshot"), ConfigFailoverDir(dir+"/failover"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close(context.Background())
+	if err = fake.PublishConfig(context.Background(), &ConfigRequest{DataID: "snapshotDataId", Group: "group", Content: "server-value"}); err != nil {
+		t.Fatal(err)
+	}
+	fake.SetError(errors.New("connection refused"))
+	for dataID, want := range map[string]string{"snapshotDataId": "snapshot-value", "failoverDataId": "failover-value"} {
+		ch := make(chan string, 1)
+		errCh := a.ListenConfig(dataID, "group", func(s string) { ch <- s })
+		select {
+		case s := <-ch:
+			if s != want {
+				t.Errorf("expected %s, got %s", want, s)
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatal("timeout waiting for local config", dataID)
+		}
+		select {
+		case err = <-errCh:
+			if err == nil {
+				t.Error("expected listen error")
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatal("timeout waiting for listen error", dataID)
+		}
+	}
+	//服务端恢复后以服务端的内容回调
+	ch := make(chan string, 2)
+	a.ListenConfig("snapshotDataId", "group", func(s string) { ch <- s })
+	select {
+	case s := <-ch:
+		if s != "snapshot-value" {
+			t.Error("expected current content for new listener", s)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for current content")
+	}
+	fake.SetError(nil)
+	select {
+	case s := <-ch:
+		if s != "server-value" {
+			t.Error("expected server value after recovery", s)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for server value")
+	}
+}
@@ -0,0 +1,88 @@
+package nacos
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestPublishConfigCAS(t *testing.T) {
+	fake := &racingTransport{FakeTransport: NewFakeTransport()}
+	a, err := NewServiceClient("", WithTransport(fake), LogLevel("error"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close(context.Background())
+	//配置不存在时直接新增
+	if err = a.PublishConfigCAS("a", "g", "1", ConfigMD5("0")); err != nil {
+		t.Fatal(err)
+	}
+	if err = a.PublishConfigCAS("a", "g", "2", ConfigMD5("0")); !errors.Is(err, ErrConfigConflict) {
+		t.Error("expected conflict", err)
+	}
+	if err = a.PublishConfigCAS("a", "g", "2", ConfigMD5("1")); err != nil {
+		t.Fatal(err)
+	}
+
+	increase := func(calls *int) func(string) (string, error) {
+		return func(current string) (string, error) {
+			*calls++
+			n, err := strconv.Atoi(current)
+			if err != nil {
+				return "", err
+			}
+			return strconv.Itoa(n + 1), nil
+		}
+	}
+	calls := 0
+	fake.content = "10"
+	content, err := a.UpdateConfig("a", "g", increase(&calls))
+	if err != nil || content != "11" || calls != 2 {
+		t.Fatal("expected update retried after conflict", content, calls, err)
+	}
+	if s, _ := a.GetConfig("a", "g"); s != "11" {
+		t.Error("unexpected config", s)
+	}
+	if _, err = a.UpdateConfig("a", "g", func(string) (string, error) { return "", strconv.ErrSyntax }); err != strconv.ErrSyntax {
+		t.Error("expected update error returned", err)
+	}
+	if _, err = a.UpdateConfig("b", "g", func(current string) (string, error) { return current + "x", nil }); err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := a.GetConfig("b", "g"); s != "x" {
+		t.Error("expected missing config created", s)
+	}
+}
+
+func TestConfigConcurrentCreate(t *testing.T) {
+	fake := &racingTransport{FakeTransport: NewFakeTransport()}
+	a, err := NewServiceClient("", WithTransport(fake), LogLevel("error"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close(context.Background())
+	//期望配置不存在
+	fake.created = "other"
+	if err = a.PublishConfigCAS("a", "g", "mine", ""); !errors.Is(err, ErrConfigConflict) {
+		t.Error("expected concurrent create to conflict", err)
+	}
+	if err = a.PublishConfigCAS("a", "g", "mine", ""); !errors.Is(err, ErrConfigConflict) {
+		t.Error("expected existing config to conflict", err)
+	}
+	if s, _ := a.GetConfig("a", "g"); s != "other" {
+		t.Error("unexpected config", s)
+	}
+
+	//新增时被其他人覆盖, 基于其他人的内容重试
+	calls := 0
+	fake.created = "other"
+	content, err := a.UpdateConfig("b", "g", func(current string) (string, error) {
+		calls++
+		return current + "+mine", nil
+	})
+	if err != nil || content != "other+mine" || calls != 2 {
+		t.Fatal("expected create retried after conflict", content, calls, err)
+	}
+
+}
@@ -0,0 +1,171 @@
+package nacos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	ConfigKeyAdded    = "ADDED"
+	ConfigKeyModified = "MODIFIED"
+	ConfigKeyDeleted  = "DELETED"
+)
+
+//ConfigKeyChange 结构化配置中单个key的变更, 嵌套的key以 "." 连接, 数组下标为 [i]
+type ConfigKeyChange struct {
+	Key      string
+	Type     string
+	OldValue string
+	NewValue string
+}
+
+//ConfigChangeEvent 配置变更事件, 首次回调时 OldContent 为空
+type ConfigChangeEvent struct {
+	DataID     string
+	Group      string
+	Tenant     string
+	OldContent string
+	NewContent string
+	OldMD5     string
+	NewMD5     string
+	//Changes 只有json/yaml/toml/properties格式的配置才有
+	Changes []*ConfigKeyChange
+}
+
+func (c *ServiceClient) ListenConfigChange(dataID string, group string, callback func(*ConfigChangeEvent), params ...Param) <-chan error {
+	return c.ListenConfigChangeWithContext(context.Background(), dataID, group, callback, params...)
+}
+
+//ListenConfigChangeWithContext 监听配置, 回调包含变更前后的内容和key级别的差异
+func (c *ServiceClient) ListenConfigChangeWithContext(ctx context.Context, dataID string, group string, callback func(*ConfigChangeEvent), params ...Param) <-chan error {
+	query := newParamMap()
+	query.Set(ParamConfigTenant(c.opts.defaultTenant))
+	query.Set(params...)
+	format := configFormat(query.tp, dataID)
+	var lock sync.Mutex
+	var old string
+	return c.ListenConfigWithContext(ctx, dataID, group, func(s string) {
+		lock.Lock()
+		defer lock.Unlock()
+		e := &ConfigChangeEvent{
+			DataID:     dataID,
+			Group:      group,
+			Tenant:     query.tenant,
+			OldContent: old,
+			NewContent: s,
+			NewMD5:     md5string(s),
+		}
+		if old != "" {
+			e.OldMD5 = md5string(old)
+		}
+		if e.OldMD5 == e.NewMD5 {
+			return
+		}
+		e.Changes = diffConfig(format, old, s)
+		old = s
+		callback(e)
+	}, params...)
+}
+
+//diffConfig 比较两个结构化配置, 无法解析时返回nil
+func diffConfig(format string, oldContent string, newContent string) []*ConfigKeyChange {
+	om, ok := flattenConfig(format, oldContent)
+	if !ok {
+		return nil
+	}
+	nm, ok := flattenConfig(format, newContent)
+	if !ok {
+		return nil
+	}
+	changes := make([]*ConfigKeyChange, 0)
+	for k, nv := range nm {
+		if ov, ok := om[k]; !ok {
+			changes = append(changes, &ConfigKeyChange{Key: k, Type: ConfigKeyAdded, NewValue: nv})
+		} else if ov != nv {
+			changes = append(changes, &ConfigKeyChange{Key: k, Type: ConfigKeyModified, OldValue: ov, NewValue: nv})
+		}
+	}
+	for k, ov := range om {
+		if _, ok := nm[k]; !ok {
+			changes = append(changes, &ConfigKeyChange{Key: k, Type: ConfigKeyDeleted, OldValue: ov})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+	return changes
+}
+
+//flattenConfig 将结构化配置展开为 key -> value
+func flattenConfig(format string, content string) (map[string]string, bool) {
+	m := make(map[string]string)
+	if content == "" {
+		return m, true
+	}
+	var v interface{}
+	var err error
+	switch format {
+	case ConfigFormatJSON:
+		err = json.Unmarshal([]byte(content), &v)
+	case ConfigFormatYAML:
+		err = yaml.Unmarshal([]byte(content), &v)
+	case ConfigFormatTOML:
+		var t map[string]interface{}
+		_, err = toml.Decode(content, &t)
+		v = t
+	case ConfigFormatProperties:
+		props, err := parseProperties(content)
+		if err != nil {
+			return nil, false
+		}
+		return props, true
+	default:
+		return nil, false
+	}
+	if err != nil {
+		return nil, false
+	}
+	switch v.(type) {
+	case map[string]interface{}, map[interface{}]interface{}:
+		flattenValue("", v, m)
+		return m, true
+	}
+	return nil, false
+}
+
+func flattenValue(prefix string, v interface{}, m map[string]string) {
+	join := func(k string) string {
+		if prefix == "" {
+			return k
+		}
+		return prefix + "." + k
+	}
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, sv := range t {
+			flattenValue(join(k), sv, m)
+		}
+	case map[interface{}]interface{}:
+		for k, sv := range t {
+			flattenValue(join(fmt.Sprint(k)), sv, m)
+		}
+	case []interface{}:
+		for i, sv := range t {
+			flattenValue(fmt.Sprintf("%s[%d]", prefix, i), sv, m)
+		}
+	case []map[string]interface{}:
+		for i, sv := range t {
+			flattenValue(fmt.Sprintf("%s[%d]", prefix, i), sv, m)
+		}
+	case nil:
+		m[prefix] = ""
+	default:
+		m[prefix] = fmt.Sprint(t)
+	}
+}
@@ -0,0 +1,27 @@
+package nacos
+
+import "testing"
+
+func Test_diffConfig(t *testing.T) {
+	changes := diffConfig(ConfigFormatYAML, "a: 1\nb:\n  c: x\n  d: [1, 2]\n", "a: 2\nb:\n  c: x\n  d: [1]\ne: new\n")
+	want := []ConfigKeyChange{
+		{Key: "a", Type: ConfigKeyModified, OldValue: "1", NewValue: "2"},
+		{Key: "b.d[1]", Type: ConfigKeyDeleted, OldValue: "2"},
+		{Key: "e", Type: ConfigKeyAdded, NewValue: "new"},
+	}
+	if len(changes) != len(want) {
+		t.Errorf("want %d changes, got %d", len(want), len(changes))
+		return
+	}
+	for i, v := range changes {
+		if *v != want[i] {
+			t.Error("unexpected change", *v)
+		}
+	}
+	if diffConfig(ConfigFormatJSON, "plain", "text") != nil {
+		t.Error("expected no key diff for unstructured content")
+	}
+	if len(diffConfig(ConfigFormatProperties, "", "a=1")) != 1 {
+		t.Error("expected added key from empty content")
+	}
+}
@@ -0,0 +1,259 @@
+package nacos
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	ConfigFormatJSON       = "json"
+	ConfigFormatYAML       = "yaml"
+	ConfigFormatTOML       = "toml"
+	ConfigFormatProperties = "properties"
+)
+
+//configFormat 优先使用 ParamConfigType 指定的格式, 否则根据dataId的扩展名判断, 默认json
+func configFormat(tp string, dataID string) string {
+	f := strings.ToLower(strings.TrimSpace(tp))
+	if f == "" {
+		f = strings.ToLower(strings.TrimPrefix(path.Ext(dataID), "."))
+	}
+	switch f {
+	case "yaml", "yml":
+		return ConfigFormatYAML
+	case "toml":
+		return ConfigFormatTOML
+	case "properties", "props":
+		return ConfigFormatProperties
+	}
+	return ConfigFormatJSON
+}
+
+//decodeConfig 将配置内容解析到 v, v 必须是指针
+func decodeConfig(format string, content string, v interface{}) error {
+	switch format {
+	case ConfigFormatJSON:
+		return json.Unmarshal([]byte(content), v)
+	case ConfigFormatYAML:
+		return yaml.Unmarshal([]byte(content), v)
+	case ConfigFormatTOML:
+		_, err := toml.Decode(content, v)
+		return err
+	case ConfigFormatProperties:
+		props, err := parseProperties(content)
+		if err != nil {
+			return err
+		}
+		return bindProperties(props, v)
+	}
+	return fmt.Errorf("unsupported config format: %s", format)
+}
+
+//parseProperties 解析java properties格式
+func parseProperties(content string) (map[string]string, error) {
+	props := make(map[string]string)
+	lines := strings.Split(strings.Replace(content, "\r\n", "\n", -1), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimLeft(lines[i], " \t\f")
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+		//行尾奇数个反斜杠表示续行
+		for endsWithContinuation(line) && i+1 < len(lines) {
+			i++
+			line = line[:len(line)-1] + strings.TrimLeft(lines[i], " \t\f")
+		}
+		key, value := splitProperty(line)
+		k, err := unescapeProperty(key)
+		if err != nil {
+			return nil, fmt.Errorf("properties line %d: %v", i+1, err)
+		}
+		v, err := unescapeProperty(value)
+		if err != nil {
+			return nil, fmt.Errorf("properties line %d: %v", i+1, err)
+		}
+		props[k] = v
+	}
+	return props, nil
+}
+
+func endsWithContinuation(line string) bool {
+	n := 0
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		n++
+	}
+	return n%2 == 1
+}
+
+func splitProperty(line string) (string, string) {
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '=', ':':
+			return line[:i], strings.TrimLeft(line[i+1:], " \t\f")
+		case ' ', '\t', '\f':
+			rest := strings.TrimLeft(line[i:], " \t\f")
+			if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+				rest = rest[1:]
+			}
+			return line[:i], strings.TrimLeft(rest, " \t\f")
+		}
+	}
+	return line, ""
+}
+
+func unescapeProperty(s string) (string, error) {
+	if !strings.Contains(s, "\\") {
+		return s, nil
+	}
+	var buf bytes.Buffer
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 >= len(s) {
+			buf.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 't':
+			buf.WriteByte('\t')
+		case 'n':
+			buf.WriteByte('\n')
+		case 'r':
+			buf.WriteByte('\r')
+		case 'f':
+			buf.WriteByte('\f')
+		case 'u':
+			if i+4 >= len(s) {
+				return "", fmt.Errorf("malformed \\u escape: %s", s)
+			}
+			r, err := strconv.ParseUint(s[i+1:i+5], 16, 32)
+			if err != nil {
+				return "", fmt.Errorf("malformed \\u escape: %s", s)
+			}
+			buf.WriteRune(rune(r))
+			i += 4
+		default:
+			buf.WriteByte(s[i])
+		}
+	}
+	return buf.String(), nil
+}
+
+//bindProperties 将properties写入 v, v 可以是 *map[string]string 或结构体指针
+//结构体字段使用 `properties:"key"` tag, 未设置时使用小写的字段名, 嵌套结构体的key以 "." 连接
+func bindProperties(props map[string]string, v interface{}) error {
+	if m, ok := v.(*map[string]string); ok {
+		if *m == nil {
+			*m = make(map[string]string)
+		}
+		for k, val := range props {
+			(*m)[k] = val
+		}
+		return nil
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("properties target must be a pointer to struct or map[string]string, got %T", v)
+	}
+	return bindPropertiesStruct(props, "", rv.Elem())
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func bindPropertiesStruct(props map[string]string, prefix string, rv reflect.Value) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		name := f.Tag.Get("properties")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		key := prefix + name
+		fv := rv.Field(i)
+		if f.Type.Kind() == reflect.Struct {
+			if err := bindPropertiesStruct(props, key+".", fv); err != nil {
+				return err
+			}
+			continue
+		}
+		s, ok := props[key]
+		if !ok {
+			continue
+		}
+		if err := setPropertyValue(fv, s); err != nil {
+			return fmt.Errorf("properties key %s: %v", key, err)
+		}
+	}
+	return nil
+}
+
+func setPropertyValue(fv reflect.Value, s string) error {
+	s = strings.TrimSpace(s)
+	if fv.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(d))
+		return nil
+	}
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(n)
+	case reflect.Slice:
+		if fv.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported slice type %s", fv.Type())
+		}
+		items := strings.Split(s, ",")
+		sl := reflect.MakeSlice(fv.Type(), 0, len(items))
+		for _, item := range items {
+			if item = strings.TrimSpace(item); item != "" {
+				sl = reflect.Append(sl, reflect.ValueOf(item).Convert(fv.Type().Elem()))
+			}
+		}
+		fv.Set(sl)
+	default:
+		return fmt.Errorf("unsupported type %s", fv.Type())
+	}
+	return nil
+}
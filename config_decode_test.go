@@ -0,0 +1,99 @@
+package nacos
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type testAppConfig struct {
+	Name    string        `json:"name" yaml:"name" toml:"name" properties:"app.name"`
+	Port    int           `json:"port" yaml:"port" toml:"port" properties:"server.port"`
+	Timeout time.Duration `json:"-" yaml:"-" toml:"-" properties:"server.timeout"`
+	Tags    []string      `json:"tags" yaml:"tags" toml:"tags" properties:"app.tags"`
+}
+
+func Test_decodeConfig(t *testing.T) {
+	cases := []struct {
+		dataID  string
+		tp      string
+		content string
+	}{
+		{"app.json", "", `{"name":"demo","port":8080,"tags":["a","b"]}`},
+		{"app.yml", "", "name: demo\nport: 8080\ntags:\n  - a\n  - b\n"},
+		{"app", "toml", "name = \"demo\"\nport = 8080\ntags = [\"a\", \"b\"]\n"},
+		{"app.properties", "", "# comment\napp.name = de\\\n  mo\nserver.port:8080\nserver.timeout 3s\napp.tags=a, b\n"},
+	}
+	for _, v := range cases {
+		cfg := new(testAppConfig)
+		format := configFormat(v.tp, v.dataID)
+		if err := decodeConfig(format, v.content, cfg); err != nil {
+			t.Error(v.dataID, err)
+			continue
+		}
+		if cfg.Name != "demo" || cfg.Port != 8080 || len(cfg.Tags) != 2 || cfg.Tags[1] != "b" {
+			t.Error(v.dataID, "unexpected config", cfg)
+		}
+		if format == ConfigFormatProperties && cfg.Timeout != 3*time.Second {
+			t.Error(v.dataID, "unexpected timeout", cfg.Timeout)
+		}
+	}
+}
+
+func Test_parseProperties(t *testing.T) {
+	props, err := parseProperties("! comment\nkey\\ with\\ space = value\\tTab\nunicode=\\u4e2d\nempty\n")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if props["key with space"] != "value\tTab" || props["unicode"] != "中" {
+		t.Error("unexpected properties", props)
+	}
+	if v, ok := props["empty"]; !ok || v != "" {
+		t.Error("expected empty key")
+	}
+}
+
+func TestBindConfig(t *testing.T) {
+	content := `{"name":"demo","port":8080}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/nacos/v1/cs/configs" {
+			_, _ = w.Write([]byte(content))
+			return
+		}
+		_ = r.ParseForm()
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+	a, err := NewServiceClient(srv.URL+"/nacos", DiscoveryIP("127.0.0.1"), LogLevel("error"))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	cfg := new(testAppConfig)
+	validate := func(v interface{}) error {
+		if v.(*testAppConfig).Port == 0 {
+			return errors.New("port required")
+		}
+		return nil
+	}
+	b, err := a.BindConfig("app.json", "group", cfg, validate)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if b.Load().(*testAppConfig) != cfg || cfg.Port != 8080 {
+		t.Error("unexpected initial value", cfg)
+	}
+	if err = b.apply(`{"name":"demo"}`, nil); err == nil {
+		t.Error("expected validation error")
+	}
+	if err = b.apply(`{"name":"demo","port":9090}`, nil); err != nil {
+		t.Error(err)
+	}
+	if b.Load().(*testAppConfig).Port != 9090 || cfg.Port != 8080 {
+		t.Error("expected value to be swapped without mutating the previous one")
+	}
+}
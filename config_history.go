@@ -0,0 +1,243 @@
+package nacos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/magicdvd/nacos-client/constant"
+)
+
+//ConfigHistory 配置的一条历史记录, 与Nacos控制台一样保存的是该次变更前的内容(新增时为新增的内容)
+type ConfigHistory struct {
+	//ID 历史记录的nid
+	ID      int64
+	DataID  string
+	Group   string
+	Tenant  string
+	AppName string
+	//MD5/Content 只有 GetConfigHistory 返回
+	MD5     string
+	Content string
+	SrcIP   string
+	SrcUser string
+	//OpType I(新增)/U(修改)/D(删除)
+	OpType           string
+	CreatedTime      time.Time
+	LastModifiedTime time.Time
+}
+
+//ConfigHistoryPage ListConfigHistory 返回的一页历史记录
+type ConfigHistoryPage struct {
+	TotalCount     int
+	PageNumber     int
+	PagesAvailable int
+	Items          []*ConfigHistory
+}
+
+type configHistoryJSON struct {
+	ID               json.RawMessage `json:"id"`
+	DataID           string          `json:"dataId"`
+	Group            string          `json:"group"`
+	Tenant           string          `json:"tenant"`
+	AppName          string          `json:"appName"`
+	MD5              string          `json:"md5"`
+	Content          string          `json:"content"`
+	SrcIP            string          `json:"srcIp"`
+	SrcUser          string          `json:"srcUser"`
+	OpType           string          `json:"opType"`
+	CreatedTime      json.RawMessage `json:"createdTime"`
+	LastModifiedTime json.RawMessage `json:"lastModifiedTime"`
+}
+
+//nacosTimeLayouts 1.x 为 2006-01-02T15:04:05.000+0000, 2.x 可能为RFC3339或毫秒时间戳
+var nacosTimeLayouts = []string{"2006-01-02T15:04:05.000-0700", time.RFC3339Nano, "2006-01-02 15:04:05"}
+
+func parseNacosTime(b json.RawMessage) time.Time {
+	s := strings.Trim(string(b), `"`)
+	if s == "" || s == "null" {
+		return time.Time{}
+	}
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(0, ms*int64(time.Millisecond))
+	}
+	for _, layout := range nacosTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (c *configHistoryJSON) history() (*ConfigHistory, error) {
+	//nid 可能是数字或字符串
+	id, err := strconv.ParseInt(strings.Trim(string(c.ID), `"`), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config history id %s: %w", c.ID, err)
+	}
+	return &ConfigHistory{
+		ID:               id,
+		DataID:           c.DataID,
+		Group:            c.Group,
+		Tenant:           c.Tenant,
+		AppName:          c.AppName,
+		MD5:              c.MD5,
+		Content:          c.Content,
+		SrcIP:            c.SrcIP,
+		SrcUser:          c.SrcUser,
+		OpType:           strings.TrimSpace(c.OpType),
+		CreatedTime:      parseNacosTime(c.CreatedTime),
+		LastModifiedTime: parseNacosTime(c.LastModifiedTime),
+	}, nil
+}
+
+func parseConfigHistory(b []byte) (*ConfigHistory, error) {
+	v := new(configHistoryJSON)
+	if err := json.Unmarshal(b, v); err != nil {
+		return nil, err
+	}
+	return v.history()
+}
+
+func parseConfigHistoryPage(b []byte) (*ConfigHistoryPage, error) {
+	v := new(struct {
+		TotalCount     int                  `json:"totalCount"`
+		PageNumber     int                  `json:"pageNumber"`
+		PagesAvailable int                  `json:"pagesAvailable"`
+		PageItems      []*configHistoryJSON `json:"pageItems"`
+	})
+	if err := json.Unmarshal(b, v); err != nil {
+		return nil, err
+	}
+	page := &ConfigHistoryPage{
+		TotalCount:     v.TotalCount,
+		PageNumber:     v.PageNumber,
+		PagesAvailable: v.PagesAvailable,
+		Items:          make([]*ConfigHistory, 0, len(v.PageItems)),
+	}
+	for _, item := range v.PageItems {
+		h, err := item.history()
+		if err != nil {
+			return nil, err
+		}
+		page.Items = append(page.Items, h)
+	}
+	return page, nil
+}
+
+func (c *ServiceClient) configQuery(dataID string, group string, params ...Param) *paramMap {
+	query := newParamMap()
+	query.Set(
+		paramConfigDataID(dataID),
+		paramConfigGroup(group),
+		ParamConfigTenant(c.opts.defaultTenant),
+	)
+	query.Set(params...)
+	return query
+}
+
+func (c *ServiceClient) ListConfigHistory(dataID string, group string, pageNo int, pageSize int, params ...Param) (*ConfigHistoryPage, error) {
+	return c.ListConfigHistoryWithContext(context.Background(), dataID, group, pageNo, pageSize, params...)
+}
+
+//ListConfigHistoryWithContext 按时间倒序分页获取历史记录, pageNo 从1开始, 不包含内容
+func (c *ServiceClient) ListConfigHistoryWithContext(ctx context.Context, dataID string, group string, pageNo int, pageSize int, params ...Param) (*ConfigHistoryPage, error) {
+	query := c.configQuery(dataID, group, params...)
+	page, err := c.configHistoryReader().ListConfigHistory(query.context(ctx), newConfigRequest(query), pageNo, pageSize)
+	if err != nil {
+		c.log.Error("ListConfigHistory", "api", err)
+		return nil, err
+	}
+	return page, nil
+}
+
+func (c *ServiceClient) GetConfigHistory(dataID string, group string, nid int64, params ...Param) (*ConfigHistory, error) {
+	return c.GetConfigHistoryWithContext(context.Background(), dataID, group, nid, params...)
+}
+
+//GetConfigHistoryWithContext 获取一条历史记录及其内容, 不存在时返回 ErrNotFound
+func (c *ServiceClient) GetConfigHistoryWithContext(ctx context.Context, dataID string, group string, nid int64, params ...Param) (*ConfigHistory, error) {
+	query := c.configQuery(dataID, group, params...)
+	h, err := c.configHistoryReader().GetConfigHistory(query.context(ctx), newConfigRequest(query), nid)
+	if err != nil {
+		c.log.Error("GetConfigHistory", "api", err)
+		return nil, err
+	}
+	return h, nil
+}
+
+func (c *ServiceClient) GetPreviousConfig(dataID string, group string, params ...Param) (*ConfigHistory, error) {
+	return c.GetPreviousConfigWithContext(context.Background(), dataID, group, params...)
+}
+
+//GetPreviousConfigWithContext 最后一次发布/删除前的内容, 即最近一条与当前内容不同的修改/删除记录
+//新增(I)的记录保存的是新增后的内容, 不是之前的版本, 会被跳过; 没有之前的版本时返回 ErrNotFound
+func (c *ServiceClient) GetPreviousConfigWithContext(ctx context.Context, dataID string, group string, params ...Param) (*ConfigHistory, error) {
+	query := c.configQuery(dataID, group, params...)
+	current, err := c.transport.GetConfig(query.context(ctx), newConfigRequest(query))
+	exists := err == nil
+	if err != nil && !errors.Is(err, ErrConfigNotFound) {
+		c.log.Error("GetPreviousConfig", "api", err)
+		return nil, err
+	}
+	for pageNo := 1; ; pageNo++ {
+		page, err := c.ListConfigHistoryWithContext(ctx, dataID, group, pageNo, constant.ConfigHistoryPageSize, params...)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range page.Items {
+			if v.OpType == "I" {
+				continue
+			}
+			h, err := c.GetConfigHistoryWithContext(ctx, dataID, group, v.ID, params...)
+			if err != nil {
+				return nil, err
+			}
+			if !exists || h.Content != current {
+				return h, nil
+			}
+		}
+		if len(page.Items) == 0 || pageNo >= page.PagesAvailable {
+			return nil, &StatusError{Code: http.StatusNotFound, Body: "config history not exist"}
+		}
+	}
+}
+
+func (c *ServiceClient) RollbackConfig(dataID string, group string, nid int64, params ...Param) error {
+	return c.RollbackConfigWithContext(context.Background(), dataID, group, nid, params...)
+}
+
+//RollbackConfigWithContext 重新发布历史记录 nid 的内容
+//发布时以读取到的当前内容做 compare-and-swap, 期间配置被其他人修改时返回 ErrConfigConflict; 配置已被删除时重新新增
+func (c *ServiceClient) RollbackConfigWithContext(ctx context.Context, dataID string, group string, nid int64, params ...Param) error {
+	h, err := c.GetConfigHistoryWithContext(ctx, dataID, group, nid, params...)
+	if err != nil {
+		return err
+	}
+	query := c.configQuery(dataID, group, params...)
+	ctx = query.context(ctx)
+	req := newConfigRequest(query)
+	current, err := c.transport.GetConfig(ctx, req)
+	switch {
+	case err == nil:
+		if current == h.Content {
+			return nil
+		}
+		req.CasMD5 = md5string(current)
+	case !errors.Is(err, ErrConfigNotFound):
+		c.log.Error("RollbackConfig", "api", err)
+		return err
+	}
+	req.Content = h.Content
+	c.log.Debug(fmt.Sprintf("rollback config dataId: %s, group: %s, tenant: %s, nid: %d", dataID, group, query.tenant, nid))
+	if err = c.publishCAS(ctx, req); err != nil {
+		c.log.Error("RollbackConfig", "api", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package nacos
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+//racingTransport 读取当前配置后模拟其他人修改配置, 新增配置后模拟其他人同时新增
+type racingTransport struct {
+	*FakeTransport
+	content string
+	created string
+}
+
+func (t *racingTransport) PublishConfig(ctx context.Context, req *ConfigRequest) error {
+	err := t.FakeTransport.PublishConfig(ctx, req)
+	if err == nil && req.CasMD5 == "" && t.created != "" {
+		r := *req
+		r.Content, t.created = t.created, ""
+		err = t.FakeTransport.PublishConfig(ctx, &r)
+	}
+	return err
+}
+
+func (t *racingTransport) GetConfig(ctx context.Context, req *ConfigRequest) (string, error) {
+	current, err := t.FakeTransport.GetConfig(ctx, req)
+	if err == nil && t.content != "" {
+		r := *req
+		r.Content, t.content = t.content, ""
+		err = t.FakeTransport.PublishConfig(ctx, &r)
+	}
+	return current, err
+}
+
+func TestConfigHistory(t *testing.T) {
+	fake := &racingTransport{FakeTransport: NewFakeTransport()}
+	a, err := NewServiceClient("", WithTransport(fake), LogLevel("error"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close(context.Background())
+	if _, err = a.GetPreviousConfig("a", "g"); !errors.Is(err, ErrNotFound) {
+		t.Error("expected no history", err)
+	}
+	//第一次发布只有新增记录, 没有之前的版本
+	if err = a.PublishConfig("a", "g", "v1"); err != nil {
+		t.Fatal(err)
+	}
+	if prev, err := a.GetPreviousConfig("a", "g"); !errors.Is(err, ErrNotFound) {
+		t.Error("expected no previous config after first publish", prev, err)
+	}
+	for _, v := range []string{"v2", "v3"} {
+		if err = a.PublishConfig("a", "g", v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	page, err := a.ListConfigHistory("a", "g", 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.TotalCount != 3 || page.PagesAvailable != 2 || len(page.Items) != 2 || page.Items[0].OpType != "U" || page.Items[1].ID >= page.Items[0].ID {
+		t.Fatal("unexpected history page", page)
+	}
+	prev, err := a.GetPreviousConfig("a", "g")
+	if err != nil || prev.Content != "v2" || prev.MD5 != md5string("v2") {
+		t.Fatal("unexpected previous config", prev, err)
+	}
+	if _, err = a.GetConfigHistory("a", "other", prev.ID); !errors.Is(err, ErrNotFound) {
+		t.Error("expected history of other config not found", err)
+	}
+
+	fake.content = "v4"
+	if err = a.RollbackConfig("a", "g", prev.ID); !errors.Is(err, ErrConfigConflict) {
+		t.Error("expected conflict", err)
+	}
+	if s, _ := a.GetConfig("a", "g"); s != "v4" {
+		t.Error("conflicting rollback should not overwrite", s)
+	}
+	if err = a.RollbackConfig("a", "g", prev.ID); err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := a.GetConfig("a", "g"); s != "v2" {
+		t.Error("unexpected config after rollback", s)
+	}
+	if prev, _ = a.GetPreviousConfig("a", "g"); prev == nil || prev.Content != "v4" {
+		t.Error("expected rollback to be recorded", prev)
+	}
+
+	if err = a.RemoveConfig("a", "g"); err != nil {
+		t.Fatal(err)
+	}
+	if prev, _ = a.GetPreviousConfig("a", "g"); prev == nil || prev.OpType != "D" || prev.Content != "v2" {
+		t.Fatal("expected delete to be recorded", prev)
+	}
+	if err = a.RollbackConfig("a", "g", prev.ID); err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := a.GetConfig("a", "g"); s != "v2" {
+		t.Error("expected deleted config restored", s)
+	}
+}
@@ -0,0 +1,380 @@
+package nacos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/magicdvd/nacos-client/constant"
+)
+
+//configListener 将所有监听的配置合并到长轮询请求中, 每个长轮询任务最多包含 constant.ConfigListenBatchSize 个配置
+type configListener struct {
+	client  *ServiceClient
+	lock    sync.Mutex
+	entries map[string]*configEntry
+	tasks   map[int]*configListenTask
+}
+
+type configEntry struct {
+	dataID   string
+	group    string
+	tenant   string
+	md5      string
+	content  string
+	params   []Param
+	taskID   int
+	watchers []*configWatcher
+}
+
+type configWatcher struct {
+	callback func(string)
+	//updates 待回调的最新内容, 由监听者自己的goroutine按顺序回调
+	updates chan string
+	errCh   chan error
+}
+
+func newConfigWatcher(callback func(string)) *configWatcher {
+	return &configWatcher{
+		callback: callback,
+		updates:  make(chan string, 1),
+		errCh:    make(chan error, 1),
+	}
+}
+
+//deliver 持有 configListener.lock 时调用, 只保留最新的内容, 不阻塞长轮询
+func (w *configWatcher) deliver(content string) {
+	select {
+	case <-w.updates:
+	default:
+	}
+	w.updates <- content
+}
+
+type configListenTask struct {
+	id         int
+	size       int
+	wake       chan struct{}
+	cancelPoll context.CancelFunc
+}
+
+func newConfigListener(client *ServiceClient) *configListener {
+	return &configListener{
+		client:  client,
+		entries: make(map[string]*configEntry),
+		tasks:   make(map[int]*configListenTask),
+	}
+}
+
+func configKey(dataID, group, tenant string) string {
+	return dataID + splitChar2 + group + splitChar2 + tenant
+}
+
+//add 添加监听, 如果该配置已经获取过则先回调当前内容
+func (c *configListener) add(dataID, group, tenant string, params []Param, w *configWatcher) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	key := configKey(dataID, group, tenant)
+	if e, ok := c.entries[key]; ok {
+		e.watchers = append(e.watchers, w)
+		if e.md5 != "" {
+			w.deliver(e.content)
+		}
+		return
+	}
+	task := c.idleTask()
+	task.size++
+	c.entries[key] = &configEntry{
+		dataID:   dataID,
+		group:    group,
+		tenant:   tenant,
+		params:   params,
+		taskID:   task.id,
+		watchers: []*configWatcher{w},
+	}
+	//中断当前的长轮询, 让新配置立即加入请求
+	if task.cancelPoll != nil {
+		task.cancelPoll()
+	}
+	select {
+	case task.wake <- struct{}{}:
+	default:
+	}
+}
+
+//idleTask 返回第一个未满的任务, 没有则新建并启动
+func (c *configListener) idleTask() *configListenTask {
+	for i := 0; ; i++ {
+		task, ok := c.tasks[i]
+		if !ok {
+			task = &configListenTask{
+				id:   i,
+				wake: make(chan struct{}, 1),
+			}
+			c.tasks[i] = task
+			c.client.goFunc(func() { c.poll(task) })
+			return task
+		}
+		if task.size < constant.ConfigListenBatchSize {
+			return task
+		}
+	}
+}
+
+func (c *configListener) remove(dataID, group, tenant string, w *configWatcher) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.removeLocked(configKey(dataID, group, tenant), w)
+}
+
+func (c *configListener) removeLocked(key string, w *configWatcher) {
+	e, ok := c.entries[key]
+	if !ok {
+		return
+	}
+	for i, v := range e.watchers {
+		if v == w {
+			e.watchers = append(e.watchers[:i], e.watchers[i+1:]...)
+			break
+		}
+	}
+	if len(e.watchers) == 0 {
+		delete(c.entries, key)
+		c.tasks[e.taskID].size--
+	}
+}
+
+//listenContexts 返回任务中所有配置的当前md5, 同时设置中断本次长轮询的 cancel,
+//之后 add 的配置一定会中断本次长轮询
+func (c *configListener) listenContexts(task *configListenTask, cancel context.CancelFunc) []*ConfigListenContext {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	task.cancelPoll = cancel
+	configs := make([]*ConfigListenContext, 0, task.size)
+	for _, e := range c.entries {
+		if e.taskID != task.id {
+			continue
+		}
+		configs = append(configs, &ConfigListenContext{
+			DataID: e.dataID,
+			Group:  e.group,
+			Tenant: e.tenant,
+			MD5:    e.md5,
+		})
+	}
+	return configs
+}
+
+//poll 长轮询出错时通知监听者并退避重试, 不会停止监听; 403时重新登录; 恢复后重新获取所有配置同步md5
+func (c *configListener) poll(task *configListenTask) {
+	ctx := c.client.ctx
+	backoff := &RetryPolicy{
+		BaseDelay: constant.DefaultRetryBaseDelay,
+		MaxDelay:  constant.ConfigListenMaxBackoff,
+		Jitter:    constant.DefaultRetryJitter,
+	}
+	failures := 0
+	for {
+		pctx, cancel := context.WithCancel(ctx)
+		configs := c.listenContexts(task, cancel)
+		if len(configs) == 0 {
+			cancel()
+			select {
+			case <-ctx.Done():
+				return
+			case <-task.wake:
+			}
+			continue
+		}
+		changed, err := c.client.transport.ListenConfigs(pctx, c.client.opts.listenInterval, configs)
+		interrupted := pctx.Err() != nil
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+		if interrupted {
+			continue
+		}
+		if err != nil {
+			c.client.log.Error("ListenConfig", "api", err)
+			for _, v := range configs {
+				key := configKey(v.DataID, v.Group, v.Tenant)
+				if v.MD5 == "" {
+					c.loadLocal(key)
+				}
+				c.notify(key, err, failures+1)
+			}
+		} else {
+			//出错期间可能错过变更, 恢复后重新获取所有配置
+			if failures > 0 {
+				changed = configs
+			}
+			for _, v := range changed {
+				if e := c.refresh(ctx, configKey(v.DataID, v.Group, v.Tenant), failures+1); e != nil {
+					err = e
+				}
+			}
+			if ctx.Err() != nil {
+				return
+			}
+		}
+		if err == nil {
+			failures = 0
+			continue
+		}
+		failures++
+		if errors.Is(err, ErrUnauthorized) {
+			if e := c.client.transport.Login(ctx); e != nil {
+				c.client.log.Error("ListenConfig", "login", e)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff.backoff(failures)):
+		}
+	}
+}
+
+//refresh 获取变更的配置, md5变化时回调; 配置被删除时通知 ErrConfigNotFound, 不计为失败
+func (c *configListener) refresh(ctx context.Context, key string, failures int) error {
+	c.lock.Lock()
+	e, ok := c.entries[key]
+	if !ok {
+		c.lock.Unlock()
+		return nil
+	}
+	dataID, group, params := e.dataID, e.group, e.params
+	c.lock.Unlock()
+	content, err := c.client.GetConfigWithContext(ctx, dataID, group, params...)
+	if ctx.Err() != nil {
+		return nil
+	}
+	if isNotFoundError(err) {
+		c.lock.Lock()
+		e, ok = c.entries[key]
+		deleted := ok && e.md5 != ""
+		if ok {
+			e.content, e.md5 = "", ""
+		}
+		c.lock.Unlock()
+		if deleted {
+			c.notify(key, err, 0)
+		}
+		return nil
+	}
+	if err != nil {
+		c.client.log.Error("ListenConfig", "api", err)
+		c.notify(key, err, failures)
+		return err
+	}
+	md5 := md5string(content)
+	c.lock.Lock()
+	if e, ok = c.entries[key]; !ok || e.md5 == md5 {
+		c.lock.Unlock()
+		return nil
+	}
+	e.content = content
+	e.md5 = md5
+	for _, w := range e.watchers {
+		w.deliver(content)
+	}
+	c.lock.Unlock()
+	return nil
+}
+
+//loadLocal 服务端不可用时, 还没有获取过的配置按 GetConfig 的顺序使用故障转移目录或本地快照的内容回调
+//之后以该内容的md5监听, 服务端恢复后重新获取
+func (c *configListener) loadLocal(key string) {
+	c.lock.Lock()
+	e, ok := c.entries[key]
+	if !ok || e.md5 != "" {
+		c.lock.Unlock()
+		return
+	}
+	dataID, group, tenant := e.dataID, e.group, e.tenant
+	c.lock.Unlock()
+	content, found := c.client.configCache.failover(tenant, group, dataID)
+	if found {
+		c.client.log.Warn("ListenConfig", "use failover config", dataID, group, tenant)
+	} else if content, found = c.client.configCache.snapshot(tenant, group, dataID); found {
+		c.client.log.Warn("ListenConfig", "use snapshot config", dataID, group, tenant)
+	}
+	if !found {
+		return
+	}
+	c.lock.Lock()
+	if e, ok = c.entries[key]; !ok || e.md5 != "" {
+		c.lock.Unlock()
+		return
+	}
+	e.content = content
+	e.md5 = md5string(content)
+	for _, w := range e.watchers {
+		w.deliver(content)
+	}
+	c.lock.Unlock()
+}
+
+//notify 将错误发送给配置的所有监听者, 监听者没有及时处理时丢弃, 不阻塞长轮询
+func (c *configListener) notify(key string, err error, failures int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return
+	}
+	for _, w := range e.watchers {
+		select {
+		case w.errCh <- &ConfigListenError{DataID: e.dataID, Group: e.group, Tenant: e.tenant, Failures: failures, Err: err}:
+		default:
+		}
+	}
+}
+
+//ConfigListenError 监听配置时的错误, 不会停止监听; 可以通过 errors.Is/As 判断 Err
+type ConfigListenError struct {
+	DataID string
+	Group  string
+	Tenant string
+	//Failures 连续失败的次数, 配置被删除时为0
+	Failures int
+	Err      error
+}
+
+func (e *ConfigListenError) Error() string {
+	return fmt.Sprintf("listen config %s/%s/%s (failures %d): %v", e.Tenant, e.Group, e.DataID, e.Failures, e.Err)
+}
+
+func (e *ConfigListenError) Unwrap() error {
+	return e.Err
+}
+
+//parseChangedConfigs 解析长轮询返回的变更配置: dataId%02group%02tenant%01
+func parseChangedConfigs(b []byte) []*ConfigListenContext {
+	changed := make([]*ConfigListenContext, 0)
+	s := strings.TrimSpace(string(b))
+	if s == "" {
+		return changed
+	}
+	s, err := url.QueryUnescape(s)
+	if err != nil {
+		return changed
+	}
+	for _, item := range strings.Split(s, splitChar1) {
+		t := strings.Split(item, splitChar2)
+		if len(t) < 2 {
+			continue
+		}
+		tenant := ""
+		if len(t) > 2 {
+			tenant = t[2]
+		}
+		changed = append(changed, &ConfigListenContext{DataID: t[0], Group: t[1], Tenant: tenant})
+	}
+	return changed
+}
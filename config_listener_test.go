@@ -0,0 +1,244 @@
+package nacos
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_parseChangedConfigs(t *testing.T) {
+	b := url.QueryEscape("a" + splitChar2 + "g" + splitChar1 + "b" + splitChar2 + "g" + splitChar2 + "dev" + splitChar1)
+	changed := parseChangedConfigs([]byte(b))
+	if len(changed) != 2 || changed[0].DataID != "a" || changed[0].Group != "g" || changed[0].Tenant != "" ||
+		changed[1].DataID != "b" || changed[1].Group != "g" || changed[1].Tenant != "dev" {
+		t.Error("unexpected changed configs", changed)
+	}
+}
+
+func TestListenConfigBatch(t *testing.T) {
+	var active, maxActive, batched int32
+	configs := map[string]string{"a": "va", "b": "vb"}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/nacos/v1/cs/configs":
+			_, _ = w.Write([]byte(configs[r.URL.Query().Get("dataId")]))
+		case "/nacos/v1/cs/configs/listener":
+			n := atomic.AddInt32(&active, 1)
+			defer atomic.AddInt32(&active, -1)
+			if n > atomic.LoadInt32(&maxActive) {
+				atomic.StoreInt32(&maxActive, n)
+			}
+			_ = r.ParseForm()
+			var changed strings.Builder
+			items := strings.Split(r.Form.Get("Listening-Configs"), splitChar1)
+			if len(items) == 3 {
+				atomic.StoreInt32(&batched, 1)
+			}
+			for _, item := range items {
+				t := strings.Split(item, splitChar2)
+				if len(t) < 3 {
+					continue
+				}
+				if t[2] != md5string(configs[t[0]]) {
+					changed.WriteString(t[0] + splitChar2 + t[1] + splitChar1)
+				}
+			}
+			if changed.Len() > 0 {
+				_, _ = w.Write([]byte(url.QueryEscape(changed.String())))
+				return
+			}
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+		}
+	}))
+	defer srv.Close()
+	a, err := NewServiceClient(srv.URL+"/nacos", DiscoveryIP("127.0.0.1"), LogLevel("error"))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	var lock sync.Mutex
+	got := make(map[string]string)
+	for _, id := range []string{"a", "b"} {
+		id := id
+		a.ListenConfig(id, "group", func(s string) {
+			lock.Lock()
+			got[id] = s
+			lock.Unlock()
+		})
+	}
+	<-time.After(500 * time.Millisecond)
+	lock.Lock()
+	if got["a"] != "va" || got["b"] != "vb" {
+		t.Error("unexpected callbacks", got)
+	}
+	lock.Unlock()
+	if atomic.LoadInt32(&maxActive) != 1 {
+		t.Error("expected a single long-poll connection, got", atomic.LoadInt32(&maxActive))
+	}
+	if atomic.LoadInt32(&batched) != 1 {
+		t.Error("expected both configs in one request")
+	}
+}
+
+func TestListenConfigRecover(t *testing.T) {
+	fake := NewFakeTransport()
+	a, err := NewServiceClient("", WithTransport(fake), ListenInterval(100*time.Millisecond), LogLevel("error"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close(context.Background())
+	ch := make(chan string, 2)
+	errCh := a.ListenConfig("a", "g", func(s string) { ch <- s })
+	expect := func(v string) {
+		select {
+		case s := <-ch:
+			if s != v {
+				t.Errorf("expected %s, got %s", v, s)
+			}
+		case err := <-errCh:
+			t.Fatal(err)
+		case <-time.After(2 * time.Second):
+			t.Fatal("timeout waiting for", v)
+		}
+	}
+	if err = a.PublishConfig("a", "g", "v1"); err != nil {
+		t.Fatal(err)
+	}
+	expect("v1")
+	unavailable := errors.New("unavailable")
+	fake.SetError(unavailable)
+	select {
+	case err := <-errCh:
+		var e *ConfigListenError
+		if !errors.Is(err, unavailable) || !errors.As(err, &e) || e.DataID != "a" || e.Failures < 1 {
+			t.Error("unexpected listen error", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout waiting for error")
+	}
+	//出错后继续监听
+	fake.SetError(nil)
+	if err = a.PublishConfig("a", "g", "v2"); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		select {
+		case s := <-ch:
+			if s != "v2" {
+				t.Errorf("expected v2, got %s", s)
+			}
+			return
+		case err, ok := <-errCh:
+			if !ok {
+				t.Fatal("listen stopped", err)
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatal("timeout waiting for v2")
+		}
+	}
+}
+
+func TestListenConfigOrder(t *testing.T) {
+	fake := NewFakeTransport()
+	a, err := NewServiceClient("", WithTransport(fake), ListenInterval(100*time.Millisecond), LogLevel("error"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close(context.Background())
+	if err = a.PublishConfig("cfg", "group", "v0"); err != nil {
+		t.Fatal(err)
+	}
+	first := make(chan string, 1)
+	a.ListenConfig("cfg", "group", func(s string) {
+		select {
+		case first <- s:
+		default:
+		}
+	})
+	select {
+	case <-first:
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for first listener")
+	}
+	//配置已经获取过, 新监听者的当前内容与随后的变更按顺序回调
+	var lock sync.Mutex
+	var got []string
+	a.ListenConfig("cfg", "group", func(s string) {
+		time.Sleep(10 * time.Millisecond)
+		lock.Lock()
+		got = append(got, s)
+		lock.Unlock()
+	})
+	if err = a.PublishConfig("cfg", "group", "v1"); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		lock.Lock()
+		last := ""
+		if len(got) > 0 {
+			last = got[len(got)-1]
+		}
+		lock.Unlock()
+		if last == "v1" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timeout waiting for change", got)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	lock.Lock()
+	defer lock.Unlock()
+	if got[len(got)-1] != "v1" || (len(got) == 2 && got[0] != "v0") || len(got) > 2 {
+		t.Error("unexpected callback order", got)
+	}
+}
+
+func TestListenConfigAddDuringPoll(t *testing.T) {
+	fake := NewFakeTransport()
+	a, err := NewServiceClient("", WithTransport(fake), ListenInterval(30*time.Second), LogLevel("error"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close(context.Background())
+	const n = 20
+	got := make(chan string, n)
+	var wg sync.WaitGroup
+	//并发添加的配置都必须中断当前的长轮询加入请求, 否则要等到长轮询超时
+	for i := 0; i < n; i++ {
+		dataID := "cfg" + strconv.Itoa(i)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a.ListenConfig(dataID, "group", func(s string) { got <- s })
+		}()
+	}
+	wg.Wait()
+	time.Sleep(50 * time.Millisecond)
+	for i := 0; i < n; i++ {
+		if err = a.PublishConfig("cfg"+strconv.Itoa(i), "group", "v"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	timeout := time.After(2 * time.Second)
+	for i := 0; i < n; i++ {
+		select {
+		case <-got:
+		case <-timeout:
+			t.Fatal("timeout waiting for callbacks", i)
+		}
+	}
+}
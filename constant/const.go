@@ -5,13 +5,28 @@ import "time"
 const (
 	ClientVersion = "nacos-go-sdk:v1.0.0"
 
-	DefaultClusterName        = "DEFAULT"
-	DefaultGroupName          = "DEFAULT_GROUP"
-	DefaultNameSpaceID        = "public"
-	DefaultTimeout            = 15 * time.Second
-	DefaultListenInterval     = 30 * time.Second
-	DefaultMaxCacheTime       = 45 * time.Second
-	DefaultSubscrubeCacheTime = 10 * time.Second
+	DefaultClusterName         = "DEFAULT"
+	DefaultGroupName           = "DEFAULT_GROUP"
+	DefaultNameSpaceID         = "public"
+	DefaultTimeout             = 15 * time.Second
+	DefaultListenInterval      = 30 * time.Second
+	DefaultMaxCacheTime        = 45 * time.Second
+	DefaultSubscrubeCacheTime  = 10 * time.Second
+	DefaultServerBlacklistTime = 30 * time.Second
+	DefaultEndpointRefreshTime = 30 * time.Second
+	DefaultContextPath         = "/nacos"
+	DefaultServerPort          = "8848"
+	ConfigListenBatchSize      = 3000
+	DefaultGRPCPortOffset      = 1000
+	GRPCHealthCheckInterval    = 5 * time.Second
+	DefaultRetryMaxAttempts    = 3
+	DefaultRetryBaseDelay      = 100 * time.Millisecond
+	DefaultRetryMaxDelay       = 3 * time.Second
+	DefaultRetryJitter         = 0.2
+	ConfigListenMaxBackoff     = 30 * time.Second
+	WarmupSteps                = 10
+	UpdateConfigMaxAttempts    = 5
+	ConfigHistoryPageSize      = 10
 
 	AccessToken        = "accessToken"
 	AccessTokenTTL     = "tokenTtl"
@@ -21,9 +36,19 @@ const (
 
 	APILoginPath    = "/v1/auth/users/login"
 	APIInstance     = "/v1/ns/instance"
+	APICluster      = "/v1/ns/cluster"
+	APIService      = "/v1/ns/service"
+	APIServiceList  = "/v1/ns/service/list"
 	APIInstanceList = "/v1/ns/instance/list"
 	APIInstanceBeat = "/v1/ns/instance/beat"
 
-	APIConfig       = "/v1/cs/configs"
-	APIConfigListen = "/v1/cs/configs/listener"
+	APINamespaces  = "/v1/console/namespaces"
+	APIServerState = "/v1/console/server/state"
+
+	APIConfig        = "/v1/cs/configs"
+	APIConfigListen  = "/v1/cs/configs/listener"
+	APIConfigHistory = "/v1/cs/history"
+
+	GRPCMethodRequest   = "/Request/request"
+	GRPCMethodBiRequest = "/BiRequestStream/requestBiStream"
 )
@@ -0,0 +1,132 @@
+package nacos
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/buger/jsonparser"
+	"github.com/magicdvd/nacos-client/constant"
+)
+
+var (
+	//ErrConfigNotFound 配置不存在, 只匹配配置接口的404
+	ErrConfigNotFound = errors.New("nacos: config not found")
+	//ErrNotFound 配置/服务/实例等资源不存在(404)
+	ErrNotFound = errors.New("nacos: not found")
+	//ErrUnauthorized 未登录, 用户名密码错误或没有权限(401/403)
+	ErrUnauthorized = errors.New("nacos: unauthorized")
+	//ErrBadRequest 参数错误(400)
+	ErrBadRequest = errors.New("nacos: bad request")
+	//ErrConfigConflict 配置的md5与期望的不一致, compare-and-swap 发布失败(409)
+	ErrConfigConflict = errors.New("nacos: config conflict")
+	//ErrServerError 服务端错误(5xx)
+	ErrServerError = errors.New("nacos: server error")
+	//ErrNotSupported 使用的 Transport 没有实现该功能对应的可选接口, 或服务端版本不支持(如 Nacos 1.x 的 CAS 发布)
+	ErrNotSupported = errors.New("nacos: not supported by transport")
+)
+
+//StatusError 服务端返回的错误, 可以通过 errors.Is 与 ErrConfigNotFound 等比较
+//
+//	var e *nacos.StatusError
+//	if errors.As(err, &e) {
+//		log.Println(e.Code, e.ErrCode, e.RequestID, e.Endpoint)
+//	}
+type StatusError struct {
+	//Code http状态码, gRPC的错误码会转换为对应的http状态码
+	Code int
+	//ErrCode Nacos的错误码, 返回内容不是json或没有错误码时为0
+	ErrCode int
+	//RequestID 请求的RequestId
+	RequestID string
+	//Endpoint 请求的地址(http) 或 服务端地址和请求类型(gRPC)
+	Endpoint string
+	//Body 返回的内容
+	Body string
+}
+
+func (e *StatusError) Error() string {
+	var sb strings.Builder
+	sb.WriteString("nacos: ")
+	if e.Endpoint != "" {
+		sb.WriteString(e.Endpoint)
+		sb.WriteString(" ")
+	}
+	fmt.Fprintf(&sb, "status %d", e.Code)
+	if e.ErrCode != 0 {
+		fmt.Fprintf(&sb, " code %d", e.ErrCode)
+	}
+	if e.RequestID != "" {
+		sb.WriteString(" requestId ")
+		sb.WriteString(e.RequestID)
+	}
+	sb.WriteString(": ")
+	sb.WriteString(e.Body)
+	return sb.String()
+}
+
+//Is 支持 errors.Is(err, ErrConfigNotFound) 等
+func (e *StatusError) Is(target error) bool {
+	switch target {
+	case ErrConfigNotFound:
+		return e.Code == http.StatusNotFound && (e.ErrCode == grpcCodeConfigNotFound || isConfigEndpoint(e.Endpoint))
+	case ErrNotFound:
+		return e.Code == http.StatusNotFound
+	case ErrUnauthorized:
+		return e.Code == http.StatusUnauthorized || e.Code == http.StatusForbidden
+	case ErrBadRequest:
+		return e.Code == http.StatusBadRequest
+	case ErrConfigConflict:
+		return e.Code == http.StatusConflict
+	case ErrServerError:
+		return e.Code >= http.StatusInternalServerError
+	}
+	return false
+}
+
+//isConfigEndpoint 只匹配配置接口本身, 不包括 /v1/cs/configs/listener 等子路径
+func isConfigEndpoint(endpoint string) bool {
+	if i := strings.IndexByte(endpoint, '?'); i >= 0 {
+		endpoint = endpoint[:i]
+	}
+	return strings.HasSuffix(endpoint, constant.APIConfig)
+}
+
+//newStatusError http请求的错误, 返回内容为json时解析Nacos的错误码和信息
+func newStatusError(code int, endpoint string, requestID string, body []byte) *StatusError {
+	e := &StatusError{
+		Code:      code,
+		RequestID: requestID,
+		Endpoint:  endpoint,
+		Body:      string(body),
+	}
+	if errCode, err := jsonparser.GetInt(body, "code"); err == nil {
+		e.ErrCode = int(errCode)
+		if message, err := jsonparser.GetString(body, "message"); err == nil && message != "" {
+			e.Body = message
+		}
+	}
+	e.Code = casStatusCode(e.Code, e.Body)
+	return e
+}
+
+//casStatusCode Nacos 的 compare-and-swap 发布失败返回500, 转换为409, 避免被当作服务端错误重试和拉黑节点
+func casStatusCode(code int, message string) int {
+	if code >= http.StatusInternalServerError && strings.HasPrefix(message, "Cas publish fail") {
+		return http.StatusConflict
+	}
+	return code
+}
+
+func isFailoverError(err error) bool {
+	var e *StatusError
+	if errors.As(err, &e) {
+		return e.Code >= http.StatusInternalServerError
+	}
+	return true
+}
+
+func isNotFoundError(err error) bool {
+	return errors.Is(err, ErrConfigNotFound)
+}
@@ -0,0 +1,75 @@
+package nacos
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestStatusError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		switch r.Form.Get("dataId") {
+		case "missing":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte("config data not exist"))
+		case "forbidden":
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"code":403,"message":"authorization failed!"}`))
+		case "":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte("Param 'dataId' is required."))
+		}
+	}))
+	defer srv.Close()
+	a, err := NewServiceClient(srv.URL+"/nacos", LogLevel("error"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = a.GetConfig("missing", "g")
+	if !errors.Is(err, ErrConfigNotFound) || errors.Is(err, ErrUnauthorized) {
+		t.Error("expected config not found", err)
+	}
+	var e *StatusError
+	if !errors.As(err, &e) || e.Code != http.StatusNotFound || e.RequestID == "" || !strings.HasSuffix(e.Endpoint, "/nacos/v1/cs/configs") {
+		t.Error("unexpected status error", e)
+	}
+	_, err = a.GetConfig("forbidden", "g")
+	if !errors.Is(err, ErrUnauthorized) || !errors.As(err, &e) || e.ErrCode != 403 || e.Body != "authorization failed!" {
+		t.Error("expected unauthorized", err)
+	}
+	if err = a.PublishConfig("", "g", "v"); !errors.Is(err, ErrBadRequest) {
+		t.Error("expected bad request", err)
+	}
+}
+
+func Test_grpcStatusCode(t *testing.T) {
+	for errorCode, want := range map[int64]int{300: 404, 403: 403, 500: 500, 20004: 500} {
+		if got := grpcStatusCode(errorCode); got != want {
+			t.Errorf("grpcStatusCode(%d) = %d, want %d", errorCode, got, want)
+		}
+	}
+}
+
+func TestStatusError_Is(t *testing.T) {
+	for _, c := range []struct {
+		err            *StatusError
+		configNotFound bool
+	}{
+		{&StatusError{Code: http.StatusNotFound, Endpoint: "http://127.0.0.1:8848/nacos/v1/cs/configs"}, true},
+		{&StatusError{Code: http.StatusNotFound, ErrCode: grpcCodeConfigNotFound, Endpoint: "127.0.0.1:9848 ConfigQueryRequest"}, true},
+		{&StatusError{Code: http.StatusNotFound, Endpoint: "http://127.0.0.1:8848/nacos/v1/ns/service"}, false},
+		{&StatusError{Code: http.StatusNotFound, Endpoint: "http://127.0.0.1:8848/nacos/v1/cs/history"}, false},
+		{&StatusError{Code: http.StatusNotFound, Endpoint: "POST http://127.0.0.1:8848/nacos/v1/cs/configs/listener"}, false},
+		{&StatusError{Code: http.StatusNotFound, Endpoint: "GET http://127.0.0.1:8848/nacos/v1/cs/configs?dataId=a"}, true},
+	} {
+		if !errors.Is(c.err, ErrNotFound) {
+			t.Error("expected not found", c.err)
+		}
+		if errors.Is(c.err, ErrConfigNotFound) != c.configNotFound {
+			t.Errorf("errors.Is(%v, ErrConfigNotFound) should be %v", c.err, c.configNotFound)
+		}
+	}
+}
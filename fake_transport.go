@@ -0,0 +1,698 @@
+package nacos
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/magicdvd/nacos-client/constant"
+)
+
+//FakeTransport 内存中的 Transport, 模拟Nacos的服务发现和配置语义, 用于不依赖真实服务端的单元测试
+//服务变更会推送给订阅者, 临时实例不需要心跳; 配置的监听按md5比较, 发布/删除时唤醒
+//
+//	fake := nacos.NewFakeTransport()
+//	client, _ := nacos.NewServiceClient("", nacos.WithTransport(fake), nacos.DiscoveryIP("127.0.0.1"))
+type FakeTransport struct {
+	lock        sync.Mutex
+	push        func(string, *Service)
+	services    map[string]map[string]*Instance
+	clusters    map[string]HealthChecker
+	definitions map[string]ServiceDefinition
+	namespaces  []*Namespace
+	subscribers map[string][]*ServiceQuery
+	configs     map[string]string
+	history     []*ConfigHistory
+	changed     chan struct{}
+	err         error
+}
+
+func NewFakeTransport() *FakeTransport {
+	return &FakeTransport{
+		services:    make(map[string]map[string]*Instance),
+		clusters:    make(map[string]HealthChecker),
+		definitions: make(map[string]ServiceDefinition),
+		namespaces:  []*Namespace{{Name: "public", Quota: 200}},
+		subscribers: make(map[string][]*ServiceQuery),
+		configs:     make(map[string]string),
+		changed:     make(chan struct{}),
+	}
+}
+
+//SetError 设置后所有请求都返回 err, 用于模拟服务端不可用, nil 恢复
+func (c *FakeTransport) SetError(err error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.err = err
+}
+
+//SetInstanceHealthy 模拟服务端健康检查修改实例的健康状态, 并推送给订阅者
+func (c *FakeTransport) SetInstanceHealthy(nameSpaceID string, grouppedServiceName string, ip string, port uint, healthy bool) bool {
+	c.lock.Lock()
+	key := nameSpaceID + "##" + grouppedServiceName
+	found := false
+	for _, v := range c.services[key] {
+		if v.Ip == ip && v.Port == uint64(port) {
+			v.Healthy = healthy
+			found = true
+		}
+	}
+	c.lock.Unlock()
+	if found {
+		c.notifySubscribers(nameSpaceID, grouppedServiceName)
+	}
+	return found
+}
+
+//HealthChecker 返回集群的健康检查方式
+func (c *FakeTransport) HealthChecker(nameSpaceID string, grouppedServiceName string, cluster string) (HealthChecker, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	h, ok := c.clusters[nameSpaceID+"##"+grouppedServiceName+"##"+cluster]
+	return h, ok
+}
+
+//Config 返回服务端当前的配置内容
+func (c *FakeTransport) Config(dataID string, group string, tenant string) (string, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	content, ok := c.configs[configKey(dataID, group, tenant)]
+	return content, ok
+}
+
+func (c *FakeTransport) Start(ctx context.Context, push func(string, *Service)) (bool, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.push = push
+	return true, nil
+}
+
+func (c *FakeTransport) Login(ctx context.Context) error {
+	return c.check(ctx)
+}
+
+func (c *FakeTransport) check(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.err
+}
+
+func fakeInstanceID(req *InstanceRequest) string {
+	return fmt.Sprintf("%s#%d#%s#%s", req.IP, req.Port, req.ClusterName, req.GrouppedServiceName())
+}
+
+func (c *FakeTransport) RegisterInstance(ctx context.Context, req *InstanceRequest) error {
+	if err := c.check(ctx); err != nil {
+		return err
+	}
+	metadata := make(map[string]string, len(req.Metadata))
+	for k, v := range req.Metadata {
+		metadata[k] = fmt.Sprint(v)
+	}
+	c.lock.Lock()
+	key := req.NameSpaceID + "##" + req.GrouppedServiceName()
+	if _, ok := c.services[key]; !ok {
+		c.services[key] = make(map[string]*Instance)
+	}
+	id := fakeInstanceID(req)
+	c.services[key][id] = &Instance{
+		Valid:       req.Healthy,
+		InstanceId:  id,
+		Port:        uint64(req.Port),
+		Ip:          req.IP,
+		Weight:      req.Weight,
+		Metadata:    metadata,
+		ClusterName: req.ClusterName,
+		ServiceName: req.GrouppedServiceName(),
+		Enable:      req.Enabled,
+		Healthy:     req.Healthy,
+		Ephemeral:   req.Ephemeral,
+	}
+	c.lock.Unlock()
+	c.notifySubscribers(req.NameSpaceID, req.GrouppedServiceName())
+	return nil
+}
+
+//UpdateInstance 实例不存在时与Nacos一样返回400
+func (c *FakeTransport) UpdateInstance(ctx context.Context, req *InstanceRequest) error {
+	if err := c.check(ctx); err != nil {
+		return err
+	}
+	c.lock.Lock()
+	_, ok := c.services[req.NameSpaceID+"##"+req.GrouppedServiceName()][fakeInstanceID(req)]
+	c.lock.Unlock()
+	if !ok {
+		return &StatusError{Code: http.StatusBadRequest, Body: "instance not exist"}
+	}
+	return c.RegisterInstance(ctx, req)
+}
+
+//UpdateCluster 服务不存在时与Nacos一样返回400
+func (c *FakeTransport) UpdateCluster(ctx context.Context, req *ClusterRequest) error {
+	if err := c.check(ctx); err != nil {
+		return err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	key := req.NameSpaceID + "##" + req.GrouppedServiceName()
+	if _, ok := c.services[key]; !ok {
+		return &StatusError{Code: http.StatusBadRequest, Body: "service not found: " + req.GrouppedServiceName()}
+	}
+	c.clusters[key+"##"+req.ClusterName] = *req.HealthChecker
+	return nil
+}
+
+//DeregisterInstance 与Nacos一样, 注销不存在的实例不会报错; ephemeral 与注册时不一致时不会删除
+func (c *FakeTransport) DeregisterInstance(ctx context.Context, req *InstanceRequest) error {
+	if err := c.check(ctx); err != nil {
+		return err
+	}
+	c.lock.Lock()
+	key := req.NameSpaceID + "##" + req.GrouppedServiceName()
+	id := fakeInstanceID(req)
+	if v, ok := c.services[key][id]; ok && v.Ephemeral == req.Ephemeral {
+		delete(c.services[key], id)
+	}
+	c.lock.Unlock()
+	c.notifySubscribers(req.NameSpaceID, req.GrouppedServiceName())
+	return nil
+}
+
+//SendBeat 实例不存在时返回20404, 客户端会重新注册
+func (c *FakeTransport) SendBeat(ctx context.Context, nameSpaceID string, beat *BeatInfo) (*BeatResult, error) {
+	if err := c.check(ctx); err != nil {
+		return nil, err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	r := &BeatResult{
+		Interval:         5 * time.Second,
+		LightBeatEnabled: true,
+		Code:             10200,
+	}
+	exist := false
+	for _, v := range c.services[nameSpaceID+"##"+beat.ServiceName] {
+		if v.Ip == beat.IP && v.Port == uint64(beat.Port) && v.ClusterName == beat.Cluster {
+			exist = true
+		}
+	}
+	if !exist {
+		r.Code = 20404
+	}
+	return r, nil
+}
+
+func (c *FakeTransport) QueryInstances(ctx context.Context, query *ServiceQuery) (*Service, error) {
+	if err := c.check(ctx); err != nil {
+		return nil, err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.service(query), nil
+}
+
+//service 按集群和健康状态过滤实例, 需持有锁
+func (c *FakeTransport) service(query *ServiceQuery) *Service {
+	clusters := make(map[string]bool, len(query.Clusters))
+	for _, v := range query.Clusters {
+		clusters[v] = true
+	}
+	svc := &Service{
+		Name:        query.GrouppedServiceName(),
+		Clusters:    strings.Join(query.Clusters, ","),
+		CacheMillis: 10000,
+		LastRefTime: time.Now().UnixNano() / int64(time.Millisecond),
+		Instances:   make([]*Instance, 0),
+	}
+	for _, v := range c.services[query.NameSpaceID+"##"+query.GrouppedServiceName()] {
+		if len(clusters) > 0 && !clusters[v.ClusterName] {
+			continue
+		}
+		if query.HealthyOnly && !v.Healthy {
+			continue
+		}
+		inst := *v
+		svc.Instances = append(svc.Instances, &inst)
+	}
+	sort.Slice(svc.Instances, func(i, j int) bool {
+		return svc.Instances[i].InstanceId < svc.Instances[j].InstanceId
+	})
+	return svc
+}
+
+//ListServices 按服务名排序分页, 不支持选择器
+func (c *FakeTransport) ListServices(ctx context.Context, req *ServiceListRequest) (*ServiceList, error) {
+	if err := c.check(ctx); err != nil {
+		return nil, err
+	}
+	c.lock.Lock()
+	prefix := req.NameSpaceID + "##" + req.GroupName + "@@"
+	names := make([]string, 0)
+	for k := range c.services {
+		if strings.HasPrefix(k, prefix) {
+			names = append(names, strings.TrimPrefix(k, prefix))
+		}
+	}
+	c.lock.Unlock()
+	sort.Strings(names)
+	list := &ServiceList{Count: len(names), Services: make([]string, 0)}
+	if start := (req.PageNo - 1) * req.PageSize; req.PageNo > 0 && start < len(names) {
+		end := start + req.PageSize
+		if end > len(names) {
+			end = len(names)
+		}
+		list.Services = append(list.Services, names[start:end]...)
+	}
+	return list, nil
+}
+
+//GetServiceDetail 服务不存在时与Nacos一样返回404
+func (c *FakeTransport) GetServiceDetail(ctx context.Context, query *ServiceQuery) (*ServiceDetail, error) {
+	if err := c.check(ctx); err != nil {
+		return nil, err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	key := query.NameSpaceID + "##" + query.GrouppedServiceName()
+	instances, ok := c.services[key]
+	if !ok {
+		return nil, &StatusError{Code: http.StatusNotFound, Body: "service " + query.GrouppedServiceName() + " is not found!"}
+	}
+	def := c.definitions[key]
+	detail := &ServiceDetail{
+		Name:             query.ServiceName,
+		GroupName:        query.GroupName,
+		NamespaceID:      query.NameSpaceID,
+		ProtectThreshold: def.ProtectThreshold,
+		Metadata:         map[string]string{},
+		Selector:         &ServiceSelector{Type: "none"},
+		Clusters:         make([]*ClusterDetail, 0),
+	}
+	for k, v := range def.Metadata {
+		detail.Metadata[k] = v
+	}
+	if def.Selector != nil {
+		selector := *def.Selector
+		detail.Selector = &selector
+	}
+	clusters := make(map[string]bool)
+	for _, v := range instances {
+		clusters[v.ClusterName] = true
+	}
+	for k := range c.clusters {
+		if strings.HasPrefix(k, key+"##") {
+			clusters[strings.TrimPrefix(k, key+"##")] = true
+		}
+	}
+	for name := range clusters {
+		h, ok := c.clusters[key+"##"+name]
+		if !ok {
+			h = HealthChecker{Type: HealthCheckerTCP}
+		}
+		detail.Clusters = append(detail.Clusters, &ClusterDetail{Name: name, HealthChecker: &h, Metadata: map[string]string{}})
+	}
+	sort.Slice(detail.Clusters, func(i, j int) bool {
+		return detail.Clusters[i].Name < detail.Clusters[j].Name
+	})
+	return detail, nil
+}
+
+//CreateService 服务已存在时与Nacos一样返回400
+func (c *FakeTransport) CreateService(ctx context.Context, req *ServiceRequest) error {
+	if err := c.check(ctx); err != nil {
+		return err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	key := req.NameSpaceID + "##" + req.GrouppedServiceName()
+	if _, ok := c.services[key]; ok {
+		return &StatusError{Code: http.StatusBadRequest, Body: "specified service already exists, serviceName : " + req.GrouppedServiceName()}
+	}
+	c.services[key] = make(map[string]*Instance)
+	c.definitions[key] = fakeServiceDefinition(req)
+	return nil
+}
+
+//UpdateService 服务不存在时与Nacos一样返回400
+func (c *FakeTransport) UpdateService(ctx context.Context, req *ServiceRequest) error {
+	if err := c.check(ctx); err != nil {
+		return err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	key := req.NameSpaceID + "##" + req.GrouppedServiceName()
+	if _, ok := c.services[key]; !ok {
+		return &StatusError{Code: http.StatusBadRequest, Body: "service not found: " + req.GrouppedServiceName()}
+	}
+	c.definitions[key] = fakeServiceDefinition(req)
+	return nil
+}
+
+//DeleteService 服务不存在或还有实例时与Nacos一样返回400
+func (c *FakeTransport) DeleteService(ctx context.Context, req *ServiceRequest) error {
+	if err := c.check(ctx); err != nil {
+		return err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	key := req.NameSpaceID + "##" + req.GrouppedServiceName()
+	instances, ok := c.services[key]
+	if !ok {
+		return &StatusError{Code: http.StatusBadRequest, Body: "specified service not exist, serviceName : " + req.GrouppedServiceName()}
+	}
+	if len(instances) > 0 {
+		return &StatusError{Code: http.StatusBadRequest, Body: "specified service has instances, serviceName : " + req.GrouppedServiceName()}
+	}
+	delete(c.services, key)
+	delete(c.definitions, key)
+	for k := range c.clusters {
+		if strings.HasPrefix(k, key+"##") {
+			delete(c.clusters, k)
+		}
+	}
+	return nil
+}
+
+func fakeServiceDefinition(req *ServiceRequest) ServiceDefinition {
+	def := ServiceDefinition{
+		ProtectThreshold: req.ProtectThreshold,
+		Metadata:         make(map[string]string, len(req.Metadata)),
+	}
+	for k, v := range req.Metadata {
+		def.Metadata[k] = v
+	}
+	if req.Selector != nil {
+		selector := *req.Selector
+		def.Selector = &selector
+	}
+	return def
+}
+
+//GetInstanceDetail 实例不存在时与Nacos一样返回404
+func (c *FakeTransport) GetInstanceDetail(ctx context.Context, req *InstanceRequest) (*InstanceDetail, error) {
+	if err := c.check(ctx); err != nil {
+		return nil, err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	v, ok := c.services[req.NameSpaceID+"##"+req.GrouppedServiceName()][fakeInstanceID(req)]
+	if !ok {
+		return nil, &StatusError{Code: http.StatusNotFound, Body: "no matched ip found!"}
+	}
+	metadata := make(map[string]string, len(v.Metadata))
+	for k, m := range v.Metadata {
+		metadata[k] = m
+	}
+	return &InstanceDetail{
+		InstanceId:  v.InstanceId,
+		Ip:          v.Ip,
+		Port:        v.Port,
+		Service:     v.ServiceName,
+		ClusterName: v.ClusterName,
+		Weight:      v.Weight,
+		Healthy:     v.Healthy,
+		Metadata:    metadata,
+	}, nil
+}
+
+//ListNamespaces 第一个为公共命名空间, ConfigCount 按配置的tenant统计
+func (c *FakeTransport) ListNamespaces(ctx context.Context) ([]*Namespace, error) {
+	if err := c.check(ctx); err != nil {
+		return nil, err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	namespaces := make([]*Namespace, 0, len(c.namespaces))
+	for _, v := range c.namespaces {
+		ns := *v
+		for k := range c.configs {
+			if strings.SplitN(k, splitChar2, 3)[2] == ns.ID {
+				ns.ConfigCount++
+			}
+		}
+		namespaces = append(namespaces, &ns)
+	}
+	return namespaces, nil
+}
+
+//namespace 需持有锁
+func (c *FakeTransport) namespace(id string) (int, bool) {
+	for i, v := range c.namespaces {
+		if v.ID == id {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+//CreateNamespace ID为空时生成; 与Nacos 1.x一样, ID已存在时返回false(400)
+func (c *FakeTransport) CreateNamespace(ctx context.Context, req *NamespaceRequest) error {
+	if err := c.check(ctx); err != nil {
+		return err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	id := req.ID
+	if id == "" {
+		id = fmt.Sprintf("namespace-%d", len(c.namespaces))
+	}
+	if _, ok := c.namespace(id); ok {
+		return &StatusError{Code: http.StatusBadRequest, Body: "false"}
+	}
+	c.namespaces = append(c.namespaces, &Namespace{ID: id, Name: req.Name, Desc: req.Desc, Quota: 200, Type: 2})
+	return nil
+}
+
+func (c *FakeTransport) UpdateNamespace(ctx context.Context, req *NamespaceRequest) error {
+	if err := c.check(ctx); err != nil {
+		return err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	i, ok := c.namespace(req.ID)
+	if !ok || req.ID == "" {
+		return &StatusError{Code: http.StatusBadRequest, Body: "false"}
+	}
+	c.namespaces[i].Name = req.Name
+	c.namespaces[i].Desc = req.Desc
+	return nil
+}
+
+//DeleteNamespace 公共命名空间不能删除
+func (c *FakeTransport) DeleteNamespace(ctx context.Context, namespaceID string) error {
+	if err := c.check(ctx); err != nil {
+		return err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if i, ok := c.namespace(namespaceID); ok && namespaceID != "" {
+		c.namespaces = append(c.namespaces[:i], c.namespaces[i+1:]...)
+		return nil
+	}
+	return &StatusError{Code: http.StatusBadRequest, Body: "false"}
+}
+
+func (c *FakeTransport) Subscribe(ctx context.Context, query *ServiceQuery) (*Service, error) {
+	if err := c.check(ctx); err != nil {
+		return nil, err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	key := query.NameSpaceID + "##" + query.GrouppedServiceName()
+	c.subscribers[key] = append(c.subscribers[key], query)
+	return c.service(query), nil
+}
+
+func (c *FakeTransport) Unsubscribe(ctx context.Context, query *ServiceQuery) error {
+	if err := c.check(ctx); err != nil {
+		return err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	key := query.NameSpaceID + "##" + query.GrouppedServiceName()
+	subscribers := c.subscribers[key][:0]
+	for _, v := range c.subscribers[key] {
+		if subscribeKey(v) != subscribeKey(query) {
+			subscribers = append(subscribers, v)
+		}
+	}
+	c.subscribers[key] = subscribers
+	return nil
+}
+
+func (c *FakeTransport) notifySubscribers(nameSpaceID string, grouppedServiceName string) {
+	c.lock.Lock()
+	push := c.push
+	services := make([]*Service, 0)
+	for _, v := range c.subscribers[nameSpaceID+"##"+grouppedServiceName] {
+		services = append(services, c.service(v))
+	}
+	c.lock.Unlock()
+	if push == nil {
+		return
+	}
+	for _, v := range services {
+		push(nameSpaceID, v)
+	}
+}
+
+func (c *FakeTransport) GetConfig(ctx context.Context, req *ConfigRequest) (string, error) {
+	if err := c.check(ctx); err != nil {
+		return "", err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	content, ok := c.configs[configKey(req.DataID, req.Group, req.Tenant)]
+	if !ok {
+		return "", &StatusError{Code: http.StatusNotFound, Endpoint: constant.APIConfig, Body: "config data not exist"}
+	}
+	return content, nil
+}
+
+//PublishConfig CasMD5 与当前内容不一致时返回409, 与Nacos一样配置不存在时直接新增
+func (c *FakeTransport) PublishConfig(ctx context.Context, req *ConfigRequest) error {
+	if err := c.check(ctx); err != nil {
+		return err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	key := configKey(req.DataID, req.Group, req.Tenant)
+	old, ok := c.configs[key]
+	if req.CasMD5 != "" && ok && md5string(old) != req.CasMD5 {
+		return &StatusError{Code: http.StatusConflict, Body: "Cas publish fail, server md5 may have changed."}
+	}
+	if !ok {
+		c.recordHistoryLocked(req, req.Content, "I")
+	} else {
+		c.recordHistoryLocked(req, old, "U")
+	}
+	c.configs[key] = req.Content
+	c.wakeLocked()
+	return nil
+}
+
+func (c *FakeTransport) RemoveConfig(ctx context.Context, req *ConfigRequest) error {
+	if err := c.check(ctx); err != nil {
+		return err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	key := configKey(req.DataID, req.Group, req.Tenant)
+	if old, ok := c.configs[key]; ok {
+		c.recordHistoryLocked(req, old, "D")
+		delete(c.configs, key)
+	}
+	c.wakeLocked()
+	return nil
+}
+
+//recordHistoryLocked 与Nacos一样, 新增时记录新内容, 修改和删除时记录变更前的内容
+func (c *FakeTransport) recordHistoryLocked(req *ConfigRequest, content string, opType string) {
+	now := time.Now()
+	c.history = append(c.history, &ConfigHistory{
+		ID:               int64(len(c.history) + 1),
+		DataID:           req.DataID,
+		Group:            req.Group,
+		Tenant:           req.Tenant,
+		AppName:          req.AppName,
+		MD5:              md5string(content),
+		Content:          content,
+		OpType:           opType,
+		CreatedTime:      now,
+		LastModifiedTime: now,
+	})
+}
+
+//ListConfigHistory 按nid倒序, 与Nacos一样不返回内容
+func (c *FakeTransport) ListConfigHistory(ctx context.Context, req *ConfigRequest, pageNo int, pageSize int) (*ConfigHistoryPage, error) {
+	if err := c.check(ctx); err != nil {
+		return nil, err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	items := make([]*ConfigHistory, 0)
+	for i := len(c.history) - 1; i >= 0; i-- {
+		if h := c.history[i]; h.DataID == req.DataID && h.Group == req.Group && h.Tenant == req.Tenant {
+			item := *h
+			item.MD5, item.Content = "", ""
+			items = append(items, &item)
+		}
+	}
+	page := &ConfigHistoryPage{TotalCount: len(items), PageNumber: pageNo, Items: make([]*ConfigHistory, 0)}
+	if pageSize > 0 {
+		page.PagesAvailable = (len(items) + pageSize - 1) / pageSize
+	}
+	if start := (pageNo - 1) * pageSize; pageNo > 0 && start < len(items) {
+		end := start + pageSize
+		if end > len(items) {
+			end = len(items)
+		}
+		page.Items = append(page.Items, items[start:end]...)
+	}
+	return page, nil
+}
+
+func (c *FakeTransport) GetConfigHistory(ctx context.Context, req *ConfigRequest, nid int64) (*ConfigHistory, error) {
+	if err := c.check(ctx); err != nil {
+		return nil, err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if nid < 1 || nid > int64(len(c.history)) {
+		return nil, &StatusError{Code: http.StatusNotFound, Body: "config history not exist"}
+	}
+	h := *c.history[nid-1]
+	if h.DataID != req.DataID || h.Group != req.Group || h.Tenant != req.Tenant {
+		return nil, &StatusError{Code: http.StatusNotFound, Body: "config history not exist"}
+	}
+	return &h, nil
+}
+
+//wakeLocked 唤醒所有等待中的 ListenConfigs
+func (c *FakeTransport) wakeLocked() {
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+func (c *FakeTransport) ListenConfigs(ctx context.Context, timeout time.Duration, configs []*ConfigListenContext) ([]*ConfigListenContext, error) {
+	deadline := time.After(timeout)
+	for {
+		if err := c.check(ctx); err != nil {
+			return nil, err
+		}
+		c.lock.Lock()
+		changed := make([]*ConfigListenContext, 0)
+		for _, v := range configs {
+			var md5 string
+			if content, ok := c.configs[configKey(v.DataID, v.Group, v.Tenant)]; ok {
+				md5 = md5string(content)
+			}
+			if md5 != v.MD5 {
+				changed = append(changed, v)
+			}
+		}
+		wake := c.changed
+		c.lock.Unlock()
+		if len(changed) > 0 {
+			return changed, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-deadline:
+			return changed, nil
+		case <-wake:
+		}
+	}
+}
+
+func (c *FakeTransport) Close() error {
+	return nil
+}
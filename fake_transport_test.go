@@ -0,0 +1,100 @@
+package nacos
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFakeTransport(t *testing.T) {
+	fake := NewFakeTransport()
+	a, err := NewServiceClient("", WithTransport(fake), DiscoveryIP("127.0.0.1"), LogLevel("error"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close(context.Background())
+	svcCh := make(chan *Service, 4)
+	if err = a.Subscribe("svc", func(s *Service) { svcCh <- s }); err != nil {
+		t.Fatal(err)
+	}
+	expectInstances := func(n int) {
+		select {
+		case s := <-svcCh:
+			if len(s.Instances) != n {
+				t.Errorf("expected %d instances, got %d", n, len(s.Instances))
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for push")
+		}
+	}
+	expectInstances(0)
+	if _, err = a.RegisterInstance("10.0.0.1", 80, "svc"); err != nil {
+		t.Fatal(err)
+	}
+	expectInstances(1)
+	if !fake.SetInstanceHealthy("public", "DEFAULT_GROUP@@svc", "10.0.0.1", 80, false) {
+		t.Fatal("instance not found")
+	}
+	expectInstances(0)
+	if _, err = a.SelectOneHealthyInstance("svc"); err != ErrNoAvailableInstance {
+		t.Error("expected no available instance", err)
+	}
+
+	if _, err = a.GetConfig("a", "g"); !isNotFoundError(err) {
+		t.Error("expected not found", err)
+	}
+	ch := make(chan string, 2)
+	errCh := a.ListenConfig("a", "g", func(s string) { ch <- s })
+	if err = a.PublishConfig("a", "g", "v1"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case s := <-ch:
+		if s != "v1" {
+			t.Error("unexpected config", s)
+		}
+	case err = <-errCh:
+		t.Fatal(err)
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for config")
+	}
+	if content, ok := fake.Config("a", "g", ""); !ok || content != "v1" {
+		t.Error("unexpected server config", content)
+	}
+}
+
+//coreTransport 只实现 Transport 的外部实现, 没有实现任何可选接口
+type coreTransport struct {
+	Transport
+}
+
+func TestOptionalTransport(t *testing.T) {
+	a, err := NewServiceClient("", WithTransport(coreTransport{NewFakeTransport()}), DiscoveryIP("127.0.0.1"), LogLevel("error"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close(context.Background())
+	r, err := a.RegisterInstance("", 80, "svc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = r.UpdateWeight(2); !errors.Is(err, ErrNotSupported) {
+		t.Error("expected update not supported", err)
+	}
+	if _, err = a.ListServices(1, 10); !errors.Is(err, ErrNotSupported) {
+		t.Error("expected catalog not supported", err)
+	}
+	if err = a.CreateService("svc2", ServiceDefinition{}); !errors.Is(err, ErrNotSupported) {
+		t.Error("expected service management not supported", err)
+	}
+	if _, err = a.ListNamespaces(); !errors.Is(err, ErrNotSupported) {
+		t.Error("expected namespaces not supported", err)
+	}
+	if _, err = a.GetPreviousConfig("a", "g"); !errors.Is(err, ErrNotSupported) {
+		t.Error("expected config history not supported", err)
+	}
+	if err = a.PublishConfig("a", "g", "v"); err != nil {
+		t.Error(err)
+	}
+}
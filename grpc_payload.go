@@ -0,0 +1,136 @@
+package nacos
+
+import (
+	"errors"
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+//grpcPayload 对应Nacos 2.x的 Payload 消息, body 为json
+//message Metadata { string type = 3; map<string, string> headers = 7; string clientIp = 8; }
+//message Payload { Metadata metadata = 2; google.protobuf.Any body = 3; }
+type grpcPayload struct {
+	tp       string
+	headers  map[string]string
+	clientIP string
+	body     []byte
+}
+
+//payloadCodec 不依赖生成代码的 Payload 编解码
+type payloadCodec struct{}
+
+func (payloadCodec) Name() string {
+	return "proto"
+}
+
+func (payloadCodec) Marshal(v interface{}) ([]byte, error) {
+	p, ok := v.(*grpcPayload)
+	if !ok {
+		return nil, fmt.Errorf("payloadCodec: unexpected type %T", v)
+	}
+	var meta []byte
+	if p.tp != "" {
+		meta = protowire.AppendTag(meta, 3, protowire.BytesType)
+		meta = protowire.AppendString(meta, p.tp)
+	}
+	for k, v := range p.headers {
+		var entry []byte
+		entry = protowire.AppendTag(entry, 1, protowire.BytesType)
+		entry = protowire.AppendString(entry, k)
+		entry = protowire.AppendTag(entry, 2, protowire.BytesType)
+		entry = protowire.AppendString(entry, v)
+		meta = protowire.AppendTag(meta, 7, protowire.BytesType)
+		meta = protowire.AppendBytes(meta, entry)
+	}
+	if p.clientIP != "" {
+		meta = protowire.AppendTag(meta, 8, protowire.BytesType)
+		meta = protowire.AppendString(meta, p.clientIP)
+	}
+	var body []byte
+	body = protowire.AppendTag(body, 2, protowire.BytesType)
+	body = protowire.AppendBytes(body, p.body)
+	var b []byte
+	b = protowire.AppendTag(b, 2, protowire.BytesType)
+	b = protowire.AppendBytes(b, meta)
+	b = protowire.AppendTag(b, 3, protowire.BytesType)
+	b = protowire.AppendBytes(b, body)
+	return b, nil
+}
+
+func (payloadCodec) Unmarshal(data []byte, v interface{}) error {
+	p, ok := v.(*grpcPayload)
+	if !ok {
+		return fmt.Errorf("payloadCodec: unexpected type %T", v)
+	}
+	return eachField(data, func(num protowire.Number, b []byte) error {
+		switch num {
+		case 2:
+			return eachField(b, func(num protowire.Number, b []byte) error {
+				switch num {
+				case 3:
+					p.tp = string(b)
+				case 7:
+					var k, v string
+					err := eachField(b, func(num protowire.Number, b []byte) error {
+						switch num {
+						case 1:
+							k = string(b)
+						case 2:
+							v = string(b)
+						}
+						return nil
+					})
+					if err != nil {
+						return err
+					}
+					if p.headers == nil {
+						p.headers = make(map[string]string)
+					}
+					p.headers[k] = v
+				case 8:
+					p.clientIP = string(b)
+				}
+				return nil
+			})
+		case 3:
+			return eachField(b, func(num protowire.Number, b []byte) error {
+				if num == 2 {
+					p.body = append([]byte{}, b...)
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+}
+
+var errInvalidPayload = errors.New("invalid grpc payload")
+
+//eachField 遍历消息的字段, 只回调 bytes 类型的字段
+func eachField(b []byte, f func(num protowire.Number, b []byte) error) error {
+	for len(b) > 0 {
+		num, tp, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return errInvalidPayload
+		}
+		b = b[n:]
+		if tp != protowire.BytesType {
+			n = protowire.ConsumeFieldValue(num, tp, b)
+			if n < 0 {
+				return errInvalidPayload
+			}
+			b = b[n:]
+			continue
+		}
+		v, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			return errInvalidPayload
+		}
+		if err := f(num, v); err != nil {
+			return err
+		}
+		b = b[n:]
+	}
+	return nil
+}
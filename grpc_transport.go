@@ -0,0 +1,726 @@
+package nacos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/buger/jsonparser"
+	"github.com/magicdvd/nacos-client/constant"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+var errGRPCNotConnected = errors.New("grpc connection is not ready")
+
+const (
+	grpcCodeOK             = 200
+	grpcCodeConfigNotFound = 300
+)
+
+var grpcStreamDesc = &grpc.StreamDesc{
+	StreamName:    "requestBiStream",
+	ServerStreams: true,
+	ClientStreams: true,
+}
+
+//grpcTransport Nacos 2.x gRPC协议
+//一个连接同时承载服务发现和配置, 临时实例随连接保活, 服务和配置变更通过双向流推送
+//连接断开后自动重连并重新注册实例和订阅, 持久化实例仍使用http
+type grpcTransport struct {
+	client   *httpClient
+	fallback *httpTransport
+	opts     *clientOptions
+	log      LogInterface
+	push     func(nameSpaceID string, service *Service)
+	ctx      context.Context
+	broken   chan struct{}
+	wg       sync.WaitGroup
+
+	lock       sync.Mutex
+	sendLock   sync.Mutex
+	conn       *grpc.ClientConn
+	cancel     context.CancelFunc
+	gen        int
+	instances  map[string]*InstanceRequest
+	subscribes map[string]*ServiceQuery
+
+	notifyLock sync.Mutex
+	notified   map[string]struct{}
+	notifyCh   chan struct{}
+}
+
+func newGRPCTransport(client *httpClient, opts *clientOptions) *grpcTransport {
+	return &grpcTransport{
+		client:     client,
+		fallback:   &httpTransport{client: client},
+		opts:       opts,
+		log:        opts.log,
+		broken:     make(chan struct{}, 1),
+		instances:  make(map[string]*InstanceRequest),
+		subscribes: make(map[string]*ServiceQuery),
+		notified:   make(map[string]struct{}),
+		notifyCh:   make(chan struct{}),
+	}
+}
+
+//Start 建立连接并启动健康检查, ctx 结束后停止重连
+func (t *grpcTransport) Start(ctx context.Context, push func(string, *Service)) (bool, error) {
+	t.ctx = ctx
+	t.push = push
+	if err := t.connect(); err != nil {
+		return false, err
+	}
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		t.keepAlive()
+	}()
+	return true, nil
+}
+
+func (t *grpcTransport) Login(ctx context.Context) error {
+	return t.fallback.Login(ctx)
+}
+
+//connect 依次尝试可用节点建立连接
+func (t *grpcTransport) connect() error {
+	var err error
+	l := t.client.servers.size()
+	if l == 0 {
+		return errNoServerAddr
+	}
+	for i := 0; i < l; i++ {
+		srv := t.client.servers.pick()
+		err = t.connectServer(srv)
+		if err == nil {
+			return nil
+		}
+		t.log.Warn("grpcConnect(failover)", srv.addr, err)
+		t.client.servers.markFailed(srv)
+	}
+	return err
+}
+
+func (t *grpcTransport) connectServer(srv *server) error {
+	addr, err := srv.grpcAddr(t.opts.grpcPortOffset)
+	if err != nil {
+		return err
+	}
+	conn, err := grpc.Dial(addr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(payloadCodec{})),
+	)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(t.ctx, constant.DefaultTimeout)
+	defer cancel()
+	if _, err = t.invoke(ctx, conn, "ServerCheckRequest", map[string]interface{}{}); err != nil {
+		conn.Close()
+		return err
+	}
+	sctx, scancel := context.WithCancel(t.ctx)
+	stream, err := conn.NewStream(sctx, grpcStreamDesc, constant.GRPCMethodBiRequest)
+	if err != nil {
+		scancel()
+		conn.Close()
+		return err
+	}
+	setup := map[string]interface{}{
+		"clientVersion": constant.ClientVersion,
+		"tenant":        "",
+		"abilities":     map[string]interface{}{},
+		"labels": map[string]string{
+			"source":  "sdk",
+			"module":  "naming,config",
+			"AppName": t.opts.appName,
+		},
+	}
+	if err = t.send(stream, "ConnectionSetupRequest", setup); err != nil {
+		scancel()
+		conn.Close()
+		return err
+	}
+	ack := make(chan struct{}, 1)
+	t.lock.Lock()
+	oldConn, oldCancel := t.conn, t.cancel
+	t.conn, t.cancel = conn, scancel
+	t.gen++
+	t.lock.Unlock()
+	if oldCancel != nil {
+		oldCancel()
+		oldConn.Close()
+	}
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		t.receive(conn, stream, ack)
+	}()
+	//等待服务端确认连接, 低版本服务端不会发送确认
+	select {
+	case <-ack:
+	case <-time.After(100 * time.Millisecond):
+	}
+	t.log.Info("grpc connected", addr)
+	return nil
+}
+
+//keepAlive 定期健康检查, 连接断开时重连并恢复实例和订阅
+func (t *grpcTransport) keepAlive() {
+	for {
+		select {
+		case <-t.ctx.Done():
+			return
+		case <-t.broken:
+		case <-time.After(constant.GRPCHealthCheckInterval):
+			ctx, cancel := context.WithTimeout(t.ctx, constant.GRPCHealthCheckInterval)
+			_, err := t.request(ctx, "HealthCheckRequest", map[string]interface{}{})
+			cancel()
+			if err == nil || t.ctx.Err() != nil {
+				continue
+			}
+			t.log.Error("grpcHealthCheck", err)
+		}
+		for {
+			err := t.connect()
+			if err == nil {
+				break
+			}
+			t.log.Error("grpcReconnect", err)
+			select {
+			case <-t.ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+		}
+		t.redo()
+		//服务端的监听已随连接丢失, 唤醒所有配置监听重新发送
+		t.notify()
+	}
+}
+
+//redo 在新连接上重新注册实例和订阅
+func (t *grpcTransport) redo() {
+	t.lock.Lock()
+	instances := make([]*InstanceRequest, 0, len(t.instances))
+	for _, v := range t.instances {
+		instances = append(instances, v)
+	}
+	subscribes := make([]*ServiceQuery, 0, len(t.subscribes))
+	for _, v := range t.subscribes {
+		subscribes = append(subscribes, v)
+	}
+	t.lock.Unlock()
+	for _, req := range instances {
+		if _, err := t.request(t.ctx, "InstanceRequest", instanceRequest(req, "registerInstance")); err != nil {
+			t.log.Error("grpcRedo", "registerInstance", req.GrouppedServiceName(), err)
+		}
+	}
+	for _, query := range subscribes {
+		service, err := t.subscribeService(t.ctx, query, true)
+		if err != nil {
+			t.log.Error("grpcRedo", "subscribe", query.GrouppedServiceName(), err)
+			continue
+		}
+		t.push(query.NameSpaceID, service)
+	}
+}
+
+func (t *grpcTransport) generation() int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.gen
+}
+
+//markBroken 只有当前连接断开时才触发重连
+func (t *grpcTransport) markBroken(conn *grpc.ClientConn) {
+	t.lock.Lock()
+	current := t.conn == conn
+	t.lock.Unlock()
+	if !current {
+		return
+	}
+	select {
+	case t.broken <- struct{}{}:
+	default:
+	}
+}
+
+//receive 处理服务端通过双向流发送的请求
+func (t *grpcTransport) receive(conn *grpc.ClientConn, stream grpc.ClientStream, ack chan struct{}) {
+	for {
+		p := new(grpcPayload)
+		if err := stream.RecvMsg(p); err != nil {
+			if t.ctx.Err() == nil {
+				t.log.Error("grpcReceive", err)
+			}
+			t.markBroken(conn)
+			return
+		}
+		requestID, _ := jsonparser.GetString(p.body, "requestId")
+		resp := map[string]interface{}{
+			"resultCode": grpcCodeOK,
+			"requestId":  requestID,
+		}
+		var respType string
+		switch p.tp {
+		case "NotifySubscriberRequest":
+			respType = "NotifySubscriberResponse"
+			nameSpaceID, _ := jsonparser.GetString(p.body, "namespace")
+			service, err := parseServiceInfo(p.body)
+			if err != nil {
+				t.log.Error("recieve push data error", nameSpaceID, err)
+				break
+			}
+			t.push(nameSpaceID, service)
+		case "ConfigChangeNotifyRequest":
+			respType = "ConfigChangeNotifyResponse"
+			dataID, _ := jsonparser.GetString(p.body, "dataId")
+			group, _ := jsonparser.GetString(p.body, "group")
+			tenant, _ := jsonparser.GetString(p.body, "tenant")
+			t.notify(configKey(dataID, group, tenant))
+		case "ClientDetectionRequest":
+			respType = "ClientDetectionResponse"
+		case "SetupAckRequest":
+			respType = "SetupAckResponse"
+			select {
+			case ack <- struct{}{}:
+			default:
+			}
+		case "ConnectResetRequest":
+			respType = "ConnectResetResponse"
+			t.markBroken(conn)
+		default:
+			t.log.Warn("grpcReceive", "unknown server request", p.tp)
+			continue
+		}
+		if err := t.send(stream, respType, resp); err != nil {
+			t.log.Error("grpcReceive", "send response", err)
+		}
+	}
+}
+
+func (t *grpcTransport) send(stream grpc.ClientStream, tp string, body map[string]interface{}) error {
+	p, err := t.payload(tp, body)
+	if err != nil {
+		return err
+	}
+	t.sendLock.Lock()
+	defer t.sendLock.Unlock()
+	return stream.SendMsg(p)
+}
+
+func (t *grpcTransport) payload(tp string, body map[string]interface{}) (*grpcPayload, error) {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	headers := map[string]string{
+		"Client-Version": constant.ClientVersion,
+		"app":            t.opts.appName,
+	}
+	if token := t.client.token(); token != "" {
+		headers[constant.AccessToken] = token
+	}
+	return &grpcPayload{
+		tp:       tp,
+		headers:  headers,
+		clientIP: t.opts.discoveryIP,
+		body:     b,
+	}, nil
+}
+
+func (t *grpcTransport) request(ctx context.Context, tp string, body map[string]interface{}) ([]byte, error) {
+	t.lock.Lock()
+	conn := t.conn
+	t.lock.Unlock()
+	if conn == nil {
+		return nil, errGRPCNotConnected
+	}
+	return t.invoke(ctx, conn, tp, body)
+}
+
+func (t *grpcTransport) invoke(ctx context.Context, conn *grpc.ClientConn, tp string, body map[string]interface{}) ([]byte, error) {
+	req, err := t.payload(tp, body)
+	if err != nil {
+		return nil, err
+	}
+	if t.client.enableLog {
+		t.log.Debug("grpcRequest", tp, string(req.body))
+	}
+	resp := new(grpcPayload)
+	if err = conn.Invoke(ctx, constant.GRPCMethodRequest, req, resp); err != nil {
+		t.log.Error("grpcRequest", tp, err)
+		return nil, err
+	}
+	if t.client.enableLog {
+		t.log.Debug("grpcResponse", resp.tp, string(resp.body))
+	}
+	code, _ := jsonparser.GetInt(resp.body, "resultCode")
+	if code != grpcCodeOK {
+		errorCode, _ := jsonparser.GetInt(resp.body, "errorCode")
+		message, _ := jsonparser.GetString(resp.body, "message")
+		if errorCode == 0 {
+			errorCode = code
+		}
+		requestID, _ := jsonparser.GetString(resp.body, "requestId")
+		return nil, &StatusError{
+			Code:      casStatusCode(grpcStatusCode(errorCode), message),
+			ErrCode:   int(errorCode),
+			RequestID: requestID,
+			Endpoint:  conn.Target() + " " + tp,
+			Body:      message,
+		}
+	}
+	return resp.body, nil
+}
+
+//grpcStatusCode 将Nacos的gRPC错误码转换为http状态码
+func grpcStatusCode(errorCode int64) int {
+	switch {
+	case errorCode == grpcCodeConfigNotFound:
+		return http.StatusNotFound
+	case errorCode >= http.StatusBadRequest && errorCode < 600:
+		return int(errorCode)
+	}
+	return http.StatusInternalServerError
+}
+
+func instanceKey(req *InstanceRequest) string {
+	return fmt.Sprintf("%s:%s:%s:%s:%s:%d", req.ServiceName, req.GroupName, req.NameSpaceID, req.ClusterName, req.IP, req.Port)
+}
+
+func subscribeKey(query *ServiceQuery) string {
+	return fmt.Sprintf("%s:%s:%s:%s", query.ServiceName, query.GroupName, query.NameSpaceID, strings.Join(query.Clusters, ","))
+}
+
+func instanceRequest(req *InstanceRequest, tp string) map[string]interface{} {
+	metadata := make(map[string]string, len(req.Metadata))
+	for k, v := range req.Metadata {
+		metadata[k] = fmt.Sprint(v)
+	}
+	return map[string]interface{}{
+		"module":      "naming",
+		"namespace":   req.NameSpaceID,
+		"serviceName": req.ServiceName,
+		"groupName":   req.GroupName,
+		"type":        tp,
+		"instance": map[string]interface{}{
+			"ip":          req.IP,
+			"port":        req.Port,
+			"weight":      req.Weight,
+			"enabled":     req.Enabled,
+			"healthy":     req.Healthy,
+			"ephemeral":   req.Ephemeral,
+			"clusterName": req.ClusterName,
+			"serviceName": req.GrouppedServiceName(),
+			"metadata":    metadata,
+		},
+	}
+}
+
+//RegisterInstance gRPC只支持临时实例, 持久化实例使用http
+func (t *grpcTransport) RegisterInstance(ctx context.Context, req *InstanceRequest) error {
+	if !req.Ephemeral {
+		return t.fallback.RegisterInstance(ctx, req)
+	}
+	_, err := t.request(ctx, "InstanceRequest", instanceRequest(req, "registerInstance"))
+	if err != nil {
+		return err
+	}
+	t.lock.Lock()
+	t.instances[instanceKey(req)] = req
+	t.lock.Unlock()
+	return nil
+}
+
+//UpdateInstance 2.x 的临时实例重新注册即为修改, 持久化实例使用http
+func (t *grpcTransport) UpdateInstance(ctx context.Context, req *InstanceRequest) error {
+	if !req.Ephemeral {
+		return t.fallback.UpdateInstance(ctx, req)
+	}
+	return t.RegisterInstance(ctx, req)
+}
+
+//UpdateCluster 2.x 仍然使用http
+func (t *grpcTransport) UpdateCluster(ctx context.Context, req *ClusterRequest) error {
+	return t.fallback.UpdateCluster(ctx, req)
+}
+
+func (t *grpcTransport) DeregisterInstance(ctx context.Context, req *InstanceRequest) error {
+	if !req.Ephemeral {
+		return t.fallback.DeregisterInstance(ctx, req)
+	}
+	t.lock.Lock()
+	delete(t.instances, instanceKey(req))
+	t.lock.Unlock()
+	_, err := t.request(ctx, "InstanceRequest", instanceRequest(req, "deregisterInstance"))
+	return err
+}
+
+func (t *grpcTransport) SendBeat(ctx context.Context, nameSpaceID string, beat *BeatInfo) (*BeatResult, error) {
+	return t.fallback.SendBeat(ctx, nameSpaceID, beat)
+}
+
+func (t *grpcTransport) QueryInstances(ctx context.Context, query *ServiceQuery) (*Service, error) {
+	b, err := t.request(ctx, "ServiceQueryRequest", map[string]interface{}{
+		"module":      "naming",
+		"namespace":   query.NameSpaceID,
+		"serviceName": query.ServiceName,
+		"groupName":   query.GroupName,
+		"cluster":     strings.Join(query.Clusters, ","),
+		"healthyOnly": query.HealthyOnly,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parseServiceInfo(b)
+}
+
+//ListServices 服务的查询和管理使用http
+func (t *grpcTransport) ListServices(ctx context.Context, req *ServiceListRequest) (*ServiceList, error) {
+	return t.fallback.ListServices(ctx, req)
+}
+
+func (t *grpcTransport) GetServiceDetail(ctx context.Context, query *ServiceQuery) (*ServiceDetail, error) {
+	return t.fallback.GetServiceDetail(ctx, query)
+}
+
+func (t *grpcTransport) CreateService(ctx context.Context, req *ServiceRequest) error {
+	return t.fallback.CreateService(ctx, req)
+}
+
+func (t *grpcTransport) UpdateService(ctx context.Context, req *ServiceRequest) error {
+	return t.fallback.UpdateService(ctx, req)
+}
+
+func (t *grpcTransport) DeleteService(ctx context.Context, req *ServiceRequest) error {
+	return t.fallback.DeleteService(ctx, req)
+}
+
+//ListNamespaces 命名空间管理使用http
+func (t *grpcTransport) ListNamespaces(ctx context.Context) ([]*Namespace, error) {
+	return t.fallback.ListNamespaces(ctx)
+}
+
+func (t *grpcTransport) CreateNamespace(ctx context.Context, req *NamespaceRequest) error {
+	return t.fallback.CreateNamespace(ctx, req)
+}
+
+func (t *grpcTransport) UpdateNamespace(ctx context.Context, req *NamespaceRequest) error {
+	return t.fallback.UpdateNamespace(ctx, req)
+}
+
+func (t *grpcTransport) DeleteNamespace(ctx context.Context, namespaceID string) error {
+	return t.fallback.DeleteNamespace(ctx, namespaceID)
+}
+
+func (t *grpcTransport) GetInstanceDetail(ctx context.Context, req *InstanceRequest) (*InstanceDetail, error) {
+	return t.fallback.GetInstanceDetail(ctx, req)
+}
+
+func (t *grpcTransport) subscribeService(ctx context.Context, query *ServiceQuery, subscribe bool) (*Service, error) {
+	b, err := t.request(ctx, "SubscribeServiceRequest", map[string]interface{}{
+		"module":      "naming",
+		"namespace":   query.NameSpaceID,
+		"serviceName": query.ServiceName,
+		"groupName":   query.GroupName,
+		"clusters":    strings.Join(query.Clusters, ","),
+		"subscribe":   subscribe,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parseServiceInfo(b)
+}
+
+func (t *grpcTransport) Subscribe(ctx context.Context, query *ServiceQuery) (*Service, error) {
+	service, err := t.subscribeService(ctx, query, true)
+	if err != nil {
+		return nil, err
+	}
+	t.lock.Lock()
+	t.subscribes[subscribeKey(query)] = query
+	t.lock.Unlock()
+	return service, nil
+}
+
+func (t *grpcTransport) Unsubscribe(ctx context.Context, query *ServiceQuery) error {
+	t.lock.Lock()
+	delete(t.subscribes, subscribeKey(query))
+	t.lock.Unlock()
+	_, err := t.subscribeService(ctx, query, false)
+	return err
+}
+
+//parseServiceInfo 2.x 的服务名不带分组, 转换为 group@@name 与1.x保持一致
+func parseServiceInfo(b []byte) (*Service, error) {
+	v, _, _, err := jsonparser.Get(b, "serviceInfo")
+	if err != nil {
+		return nil, err
+	}
+	service, err := parseServiceJSON(v)
+	if err != nil {
+		return nil, err
+	}
+	groupName, _ := jsonparser.GetString(v, "groupName")
+	if groupName != "" && !strings.Contains(service.Name, "@@") {
+		service.Name = groupName + "@@" + service.Name
+	}
+	if service.Instances == nil {
+		service.Instances = make([]*Instance, 0)
+	}
+	return service, nil
+}
+
+func (t *grpcTransport) GetConfig(ctx context.Context, req *ConfigRequest) (string, error) {
+	b, err := t.request(ctx, "ConfigQueryRequest", map[string]interface{}{
+		"module": "config",
+		"dataId": req.DataID,
+		"group":  req.Group,
+		"tenant": req.Tenant,
+		"tag":    req.Tag,
+	})
+	if err != nil {
+		return "", err
+	}
+	content, err := jsonparser.GetString(b, "content")
+	if err != nil && err != jsonparser.KeyPathNotFoundError {
+		return "", err
+	}
+	return content, nil
+}
+
+func (t *grpcTransport) PublishConfig(ctx context.Context, req *ConfigRequest) error {
+	_, err := t.request(ctx, "ConfigPublishRequest", map[string]interface{}{
+		"module":  "config",
+		"dataId":  req.DataID,
+		"group":   req.Group,
+		"tenant":  req.Tenant,
+		"content": req.Content,
+		"casMd5":  req.CasMD5,
+		"additionMap": map[string]string{
+			"type":        req.Type,
+			"appName":     req.AppName,
+			"config_tags": req.Tag,
+		},
+	})
+	return err
+}
+
+//ListConfigHistory 历史记录使用http
+func (t *grpcTransport) ListConfigHistory(ctx context.Context, req *ConfigRequest, pageNo int, pageSize int) (*ConfigHistoryPage, error) {
+	return t.fallback.ListConfigHistory(ctx, req, pageNo, pageSize)
+}
+
+func (t *grpcTransport) GetConfigHistory(ctx context.Context, req *ConfigRequest, nid int64) (*ConfigHistory, error) {
+	return t.fallback.GetConfigHistory(ctx, req, nid)
+}
+
+func (t *grpcTransport) RemoveConfig(ctx context.Context, req *ConfigRequest) error {
+	_, err := t.request(ctx, "ConfigRemoveRequest", map[string]interface{}{
+		"module": "config",
+		"dataId": req.DataID,
+		"group":  req.Group,
+		"tenant": req.Tenant,
+		"tag":    req.Tag,
+	})
+	return err
+}
+
+//notify 记录变更的配置并唤醒所有等待中的监听
+func (t *grpcTransport) notify(keys ...string) {
+	t.notifyLock.Lock()
+	defer t.notifyLock.Unlock()
+	for _, k := range keys {
+		t.notified[k] = struct{}{}
+	}
+	close(t.notifyCh)
+	t.notifyCh = make(chan struct{})
+}
+
+//takeNotified 取出 configs 中已收到变更通知的配置
+func (t *grpcTransport) takeNotified(configs []*ConfigListenContext) ([]*ConfigListenContext, chan struct{}) {
+	t.notifyLock.Lock()
+	defer t.notifyLock.Unlock()
+	changed := make([]*ConfigListenContext, 0)
+	for _, v := range configs {
+		k := configKey(v.DataID, v.Group, v.Tenant)
+		if _, ok := t.notified[k]; ok {
+			delete(t.notified, k)
+			changed = append(changed, v)
+		}
+	}
+	return changed, t.notifyCh
+}
+
+//listenConfigs 注册监听并检查md5, 没有变更时等待服务端推送或超时
+func (t *grpcTransport) ListenConfigs(ctx context.Context, timeout time.Duration, configs []*ConfigListenContext) ([]*ConfigListenContext, error) {
+	contexts := make([]map[string]string, 0, len(configs))
+	for _, v := range configs {
+		contexts = append(contexts, map[string]string{
+			"dataId": v.DataID,
+			"group":  v.Group,
+			"tenant": v.Tenant,
+			"md5":    v.MD5,
+		})
+	}
+	deadline := time.After(timeout)
+	for {
+		gen := t.generation()
+		b, err := t.request(ctx, "ConfigBatchListenRequest", map[string]interface{}{
+			"module":               "config",
+			"listen":               true,
+			"configListenContexts": contexts,
+		})
+		if err != nil {
+			return nil, err
+		}
+		changed := make([]*ConfigListenContext, 0)
+		_, _ = jsonparser.ArrayEach(b, func(v []byte, _ jsonparser.ValueType, _ int, _ error) {
+			c := new(ConfigListenContext)
+			c.DataID, _ = jsonparser.GetString(v, "dataId")
+			c.Group, _ = jsonparser.GetString(v, "group")
+			c.Tenant, _ = jsonparser.GetString(v, "tenant")
+			changed = append(changed, c)
+		}, "changedConfigs")
+		for {
+			notified, wake := t.takeNotified(configs)
+			changed = append(changed, notified...)
+			if len(changed) > 0 {
+				return changed, nil
+			}
+			//重连后服务端的监听已丢失, 需要重新注册
+			if t.generation() != gen {
+				break
+			}
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-deadline:
+				return changed, nil
+			case <-wake:
+			}
+		}
+	}
+}
+
+func (t *grpcTransport) Close() error {
+	t.lock.Lock()
+	conn, cancel := t.conn, t.cancel
+	t.conn = nil
+	t.lock.Unlock()
+	var err error
+	if conn != nil {
+		cancel()
+		err = conn.Close()
+	}
+	t.wg.Wait()
+	return err
+}
@@ -0,0 +1,173 @@
+package nacos
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/buger/jsonparser"
+	"google.golang.org/grpc"
+)
+
+func Test_payloadCodec(t *testing.T) {
+	p := &grpcPayload{
+		tp:       "ConfigQueryRequest",
+		headers:  map[string]string{"accessToken": "abc", "app": "test"},
+		clientIP: "127.0.0.1",
+		body:     []byte(`{"dataId":"a"}`),
+	}
+	b, err := payloadCodec{}.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := new(grpcPayload)
+	if err = (payloadCodec{}).Unmarshal(b, r); err != nil {
+		t.Fatal(err)
+	}
+	if r.tp != p.tp || r.clientIP != p.clientIP || string(r.body) != string(p.body) || r.headers["accessToken"] != "abc" || r.headers["app"] != "test" {
+		t.Error("unexpected payload", r)
+	}
+}
+
+//fakeGRPCServer 模拟Nacos 2.x的gRPC服务端
+type fakeGRPCServer struct {
+	lock    sync.Mutex
+	configs map[string]string
+	streams []grpc.ServerStream
+	sendMu  sync.Mutex
+}
+
+func (s *fakeGRPCServer) handle(srv interface{}, stream grpc.ServerStream) error {
+	method, _ := grpc.MethodFromServerStream(stream)
+	if method == "/BiRequestStream/requestBiStream" {
+		p := new(grpcPayload)
+		if err := stream.RecvMsg(p); err != nil || p.tp != "ConnectionSetupRequest" {
+			return err
+		}
+		s.lock.Lock()
+		s.streams = append(s.streams, stream)
+		s.lock.Unlock()
+		for {
+			if err := stream.RecvMsg(new(grpcPayload)); err != nil {
+				return nil
+			}
+		}
+	}
+	req := new(grpcPayload)
+	if err := stream.RecvMsg(req); err != nil {
+		return err
+	}
+	resp := map[string]interface{}{"resultCode": 200}
+	dataID, _ := jsonparser.GetString(req.body, "dataId")
+	switch req.tp {
+	case "ServerCheckRequest":
+		resp["connectionId"] = "1"
+	case "ConfigQueryRequest":
+		s.lock.Lock()
+		content, ok := s.configs[dataID]
+		s.lock.Unlock()
+		if !ok {
+			resp["resultCode"] = 500
+			resp["errorCode"] = 300
+			resp["message"] = "config data not exist"
+		}
+		resp["content"] = content
+	case "ConfigPublishRequest":
+		content, _ := jsonparser.GetString(req.body, "content")
+		s.lock.Lock()
+		s.configs[dataID] = content
+		s.lock.Unlock()
+		group, _ := jsonparser.GetString(req.body, "group")
+		s.push("ConfigChangeNotifyRequest", map[string]interface{}{"dataId": dataID, "group": group, "tenant": ""})
+	case "ConfigBatchListenRequest":
+		changed := make([]map[string]string, 0)
+		_, _ = jsonparser.ArrayEach(req.body, func(v []byte, _ jsonparser.ValueType, _ int, _ error) {
+			dataID, _ := jsonparser.GetString(v, "dataId")
+			group, _ := jsonparser.GetString(v, "group")
+			md5, _ := jsonparser.GetString(v, "md5")
+			s.lock.Lock()
+			content, ok := s.configs[dataID]
+			s.lock.Unlock()
+			if ok && md5string(content) != md5 {
+				changed = append(changed, map[string]string{"dataId": dataID, "group": group})
+			}
+		}, "configListenContexts")
+		resp["changedConfigs"] = changed
+	case "SubscribeServiceRequest", "ServiceQueryRequest":
+		resp["serviceInfo"] = map[string]interface{}{
+			"name":      "svc",
+			"groupName": "DEFAULT_GROUP",
+			"hosts":     []map[string]interface{}{{"ip": "10.0.0.1", "port": 80, "weight": 1, "healthy": true, "enabled": true}},
+		}
+	}
+	b, _ := json.Marshal(resp)
+	return stream.SendMsg(&grpcPayload{tp: "Response", body: b})
+}
+
+func (s *fakeGRPCServer) push(tp string, body map[string]interface{}) {
+	b, _ := json.Marshal(body)
+	s.lock.Lock()
+	streams := append([]grpc.ServerStream{}, s.streams...)
+	s.lock.Unlock()
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+	for _, stream := range streams {
+		_ = stream.SendMsg(&grpcPayload{tp: tp, body: b})
+	}
+}
+
+func TestGRPCTransport(t *testing.T) {
+	fake := &fakeGRPCServer{configs: map[string]string{"a": "v1"}}
+	gs := grpc.NewServer(grpc.ForceServerCodec(payloadCodec{}), grpc.UnknownServiceHandler(fake.handle))
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() { _ = gs.Serve(lis) }()
+	defer gs.Stop()
+	a, err := NewServiceClient("http://"+lis.Addr().String()+"/nacos", EnableGRPC(true), GRPCPortOffset(0), DiscoveryIP("127.0.0.1"), LogLevel("error"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close(context.Background())
+	if _, ok := a.(*ServiceClient).transport.(*grpcTransport); !ok {
+		t.Fatal("expected grpc transport")
+	}
+	if _, err = a.GetConfig("missing", "g"); !isNotFoundError(err) {
+		t.Error("expected not found error", err)
+	}
+	ch := make(chan string, 2)
+	errCh := a.ListenConfig("a", "g", func(s string) { ch <- s })
+	expect := func(v string) {
+		select {
+		case s := <-ch:
+			if s != v {
+				t.Errorf("expected %s, got %s", v, s)
+			}
+		case err := <-errCh:
+			t.Fatal(err)
+		case <-time.After(2 * time.Second):
+			t.Fatal("timeout waiting for", v)
+		}
+	}
+	expect("v1")
+	if err = a.PublishConfig("a", "g", "v2"); err != nil {
+		t.Fatal(err)
+	}
+	expect("v2")
+	svcCh := make(chan *Service, 1)
+	if err = a.Subscribe("svc", func(s *Service) { svcCh <- s }); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case s := <-svcCh:
+		if s.Name != "DEFAULT_GROUP@@svc" || len(s.Instances) != 1 {
+			t.Error("unexpected service", s.Name, s.Instances)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout waiting for service")
+	}
+}
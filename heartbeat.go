@@ -0,0 +1,162 @@
+package nacos
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	//heartbeatEventBuffer 每个实例事件channel的缓冲, 没有及时读取的事件会被丢弃
+	heartbeatEventBuffer = 16
+	//heartbeatErrBuffer 客户端 HeartBeatErr channel的缓冲
+	heartbeatErrBuffer = 64
+)
+
+//Heartbeat 临时实例的心跳状态, 通过 Registration.Heartbeat 或 ServiceClient.Heartbeat 获取
+//gRPC 等推送模式下实例随连接保活, 不发送心跳, 状态始终为健康
+type Heartbeat struct {
+	nameSpaceID string
+	beat        *BeatInfo
+	done        chan struct{}
+	events      chan *HeartbeatEvent
+	lock        sync.Mutex
+	status      HeartbeatStatus
+}
+
+//HeartbeatStatus 心跳状态
+type HeartbeatStatus struct {
+	//Healthy 最近一次心跳是否成功
+	Healthy bool
+	//LastSuccess 最近一次心跳成功的时间
+	LastSuccess time.Time
+	//ConsecutiveFailures 连续失败的次数
+	ConsecutiveFailures int
+	LastError           error
+	//Stopped 已注销, 或连续失败超过 MaxHeartBeatRetryTimes 后停止发送心跳
+	Stopped bool
+}
+
+//HeartbeatEvent 心跳失败或恢复的事件, Err 为nil表示失败后恢复
+type HeartbeatEvent struct {
+	NameSpaceID string
+	//ServiceName group@@serviceName
+	ServiceName string
+	Cluster     string
+	IP          string
+	Port        uint
+	Time        time.Time
+	//Failures 连续失败的次数, 恢复时为恢复前的失败次数
+	Failures int
+	//Stopped 本次失败后停止发送心跳
+	Stopped bool
+	Err     error
+}
+
+func (e *HeartbeatEvent) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("heartbeat %s %s %s:%d recovered after %d failures", e.NameSpaceID, e.ServiceName, e.IP, e.Port, e.Failures)
+	}
+	return fmt.Sprintf("heartbeat %s %s %s:%d (failures %d): %v", e.NameSpaceID, e.ServiceName, e.IP, e.Port, e.Failures, e.Err)
+}
+
+func (e *HeartbeatEvent) Unwrap() error {
+	return e.Err
+}
+
+func newHeartbeat(nameSpaceID string, beat *BeatInfo) *Heartbeat {
+	return &Heartbeat{
+		nameSpaceID: nameSpaceID,
+		beat:        beat,
+		done:        make(chan struct{}),
+		events:      make(chan *HeartbeatEvent, heartbeatEventBuffer),
+	}
+}
+
+//snapshot 返回当前心跳内容的副本, 发送心跳时使用
+func (h *Heartbeat) snapshot() *BeatInfo {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	b := *h.beat
+	return &b
+}
+
+//update 修改心跳内容, 与发送心跳并发安全
+func (h *Heartbeat) update(f func(*BeatInfo)) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	f(h.beat)
+}
+
+//Status 返回当前的心跳状态
+func (h *Heartbeat) Status() HeartbeatStatus {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.status
+}
+
+//Events 心跳失败和恢复的事件, 注销或停止发送心跳后关闭; 没有及时读取的事件会被丢弃, 不会阻塞心跳
+func (h *Heartbeat) Events() <-chan *HeartbeatEvent {
+	return h.events
+}
+
+//record 记录一次心跳结果, 失败或恢复时返回事件
+func (h *Heartbeat) record(err error, maxFailures int) *HeartbeatEvent {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if h.status.Stopped {
+		return nil
+	}
+	e := &HeartbeatEvent{
+		NameSpaceID: h.nameSpaceID,
+		ServiceName: h.beat.ServiceName,
+		Cluster:     h.beat.Cluster,
+		IP:          h.beat.IP,
+		Port:        h.beat.Port,
+		Time:        time.Now(),
+		Err:         err,
+	}
+	if err == nil {
+		e.Failures = h.status.ConsecutiveFailures
+		h.status.Healthy = true
+		h.status.LastSuccess = e.Time
+		h.status.ConsecutiveFailures = 0
+		h.status.LastError = nil
+		if e.Failures == 0 {
+			return nil
+		}
+	} else {
+		h.status.Healthy = false
+		h.status.ConsecutiveFailures++
+		h.status.LastError = err
+		e.Failures = h.status.ConsecutiveFailures
+		if maxFailures >= 0 && e.Failures > maxFailures {
+			h.status.Stopped = true
+			e.Stopped = true
+		}
+	}
+	select {
+	case h.events <- e:
+	default:
+	}
+	//不再发送心跳, 与注销一样关闭事件channel
+	if e.Stopped {
+		close(h.done)
+		close(h.events)
+	}
+	return e
+}
+
+//stop 注销时停止心跳并关闭事件channel, 已经停止时忽略
+func (h *Heartbeat) stop() {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	select {
+	case <-h.done:
+		return
+	default:
+	}
+	h.status.Stopped = true
+	close(h.done)
+	close(h.events)
+}
@@ -0,0 +1,167 @@
+package nacos
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHeartbeat(t *testing.T) {
+	var failing int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		if r.URL.Path == "/nacos/v1/ns/instance/beat" {
+			if atomic.LoadInt32(&failing) == 1 && r.Form.Get("clusterName") == "aa" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_, _ = w.Write([]byte(`{"clientBeatInterval":20,"code":10200,"lightBeatEnabled":true}`))
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+	a, err := NewServiceClient(srv.URL+"/nacos", DiscoveryIP("127.0.0.1"), MaxHeartBeatRetryTimes(100), LogLevel("error"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close(context.Background())
+	for _, cluster := range []string{"aa", "bb"} {
+		if _, err = a.RegisterInstance("", 80, "svc", ParamClusterName(cluster)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	aa, ok := a.Heartbeat("", 80, "svc", ParamClusterName("aa"))
+	if !ok || !aa.Status().Healthy {
+		t.Fatal("expected healthy heartbeat")
+	}
+	bb, _ := a.Heartbeat("", 80, "svc", ParamClusterName("bb"))
+	atomic.StoreInt32(&failing, 1)
+	select {
+	case err := <-a.HeartBeatErr():
+		e, ok := err.(*HeartbeatEvent)
+		if !ok || e.Cluster != "aa" || e.Failures < 1 || e.Err == nil {
+			t.Error("unexpected heartbeat error", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for heartbeat error")
+	}
+	if s := aa.Status(); s.Healthy || s.ConsecutiveFailures < 1 || s.LastError == nil {
+		t.Error("expected unhealthy status", s)
+	}
+	if s := bb.Status(); !s.Healthy || time.Since(s.LastSuccess) > time.Second {
+		t.Error("expected healthy status", s)
+	}
+	atomic.StoreInt32(&failing, 0)
+	timeout := time.After(time.Second)
+	for recovered := false; !recovered; {
+		select {
+		case e := <-aa.Events():
+			recovered = e.Err == nil
+		case <-timeout:
+			t.Fatal("timeout waiting for recovery")
+		}
+	}
+	if err = a.DeregisterInstance("", 80, "svc", ParamClusterName("aa")); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok = a.Heartbeat("", 80, "svc", ParamClusterName("aa")); ok || !aa.Status().Stopped {
+		t.Error("expected heartbeat stopped")
+	}
+}
+
+func TestHeartbeat_Stopped(t *testing.T) {
+	var failing int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/nacos/v1/ns/instance/beat" {
+			if atomic.LoadInt32(&failing) == 1 {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_, _ = w.Write([]byte(`{"clientBeatInterval":20,"code":10200,"lightBeatEnabled":true}`))
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+	a, err := NewServiceClient(srv.URL+"/nacos", DiscoveryIP("127.0.0.1"), MaxHeartBeatRetryTimes(1), LogLevel("error"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := a.RegisterInstance("", 80, "svc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	atomic.StoreInt32(&failing, 1)
+	timeout := time.After(time.Second)
+	var last *HeartbeatEvent
+	for closed := false; !closed; {
+		select {
+		case e, ok := <-r.Heartbeat().Events():
+			if !ok {
+				closed = true
+				break
+			}
+			last = e
+		case <-timeout:
+			t.Fatal("timeout waiting for events channel closed")
+		}
+	}
+	if last == nil || !last.Stopped || !r.Heartbeat().Status().Stopped {
+		t.Error("expected heartbeat stopped", last)
+	}
+	if err = a.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	timeout = time.After(time.Second)
+	for closed := false; !closed; {
+		select {
+		case _, ok := <-a.HeartBeatErr():
+			closed = !ok
+		case <-timeout:
+			t.Fatal("timeout waiting for HeartBeatErr closed")
+		}
+	}
+}
+
+func TestHeartbeat_Relogin(t *testing.T) {
+	var logins int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/nacos/v1/auth/users/login":
+			atomic.AddInt32(&logins, 1)
+			_, _ = w.Write([]byte(`{"accessToken":"token","tokenTtl":18000}`))
+		case "/nacos/v1/ns/instance/beat":
+			if atomic.LoadInt32(&logins) > 1 {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			_, _ = w.Write([]byte(`{"clientBeatInterval":20,"code":10200,"lightBeatEnabled":true}`))
+		default:
+			_, _ = w.Write([]byte("ok"))
+		}
+	}))
+	defer srv.Close()
+	a, err := NewServiceClient("http://nacos:nacos@"+srv.Listener.Addr().String()+"/nacos", DiscoveryIP("127.0.0.1"), MaxHeartBeatRetryTimes(100), LogLevel("error"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close(context.Background())
+	if _, err = a.RegisterInstance("", 80, "svc"); err != nil {
+		t.Fatal(err)
+	}
+	atomic.AddInt32(&logins, 1)
+	//心跳失败后的重新登录与其他请求并发读写accessToken
+	deadline := time.Now().Add(200 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		if _, err = a.GetConfig("cfg", "DEFAULT_GROUP"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := atomic.LoadInt32(&logins); n < 4 {
+		t.Error("expected relogin after heartbeat failures", n)
+	}
+}
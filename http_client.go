@@ -1,6 +1,7 @@
 package nacos
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -8,6 +9,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/buger/jsonparser"
@@ -16,8 +18,9 @@ import (
 )
 
 type httpClient struct {
-	addr            string
-	contextPath     string
+	servers         *serverList
+	endpoint        string
+	tokenLock       sync.RWMutex
 	accessToken     string
 	accessTokenTTL  int64
 	lastRefreshTime time.Time
@@ -28,9 +31,10 @@ type httpClient struct {
 	enableLog       bool
 	log             LogInterface
 	loginExit       bool
+	retryPolicy     *RetryPolicy
 }
 
-func (c *httpClient) listen(method, apiURI string, t time.Duration, params, body *paramMap) ([]byte, error) {
+func (c *httpClient) listen(ctx context.Context, method, apiURI string, t time.Duration, params, body *paramMap) ([]byte, error) {
 	headers := map[string]string{}
 	headers["Client-Version"] = constant.ClientVersion
 	headers["User-Agent"] = constant.ClientVersion
@@ -50,13 +54,19 @@ func (c *httpClient) listen(method, apiURI string, t time.Duration, params, body
 	if body != nil {
 		bodyData = body.Parse()
 	}
-	if c.accessToken != "" {
-		query.Set(constant.AccessToken, c.accessToken)
+	if token := c.token(); token != "" {
+		query.Set(constant.AccessToken, token)
 	}
-	return c.do(c.listenClient, method, c.addr+c.contextPath+apiURI, headers, query, bodyData)
+	//长轮询不重试, 失败后由配置监听退避重试
+	return c.requestOnce(ctx, c.listenClient, method, apiURI, headers, query, bodyData)
 }
 
-func (c *httpClient) api(method, apiURI string, params, body *paramMap) ([]byte, error) {
+func (c *httpClient) api(ctx context.Context, method, apiURI string, params, body *paramMap) ([]byte, error) {
+	return c.apiWithHeaders(ctx, method, apiURI, nil, params, body)
+}
+
+//apiWithHeaders 带上额外的请求头, 如发布配置时的casMd5
+func (c *httpClient) apiWithHeaders(ctx context.Context, method, apiURI string, extra map[string]string, params, body *paramMap) ([]byte, error) {
 	headers := map[string]string{}
 	headers["Client-Version"] = constant.ClientVersion
 	headers["User-Agent"] = constant.ClientVersion
@@ -68,6 +78,9 @@ func (c *httpClient) api(method, apiURI string, params, body *paramMap) ([]byte,
 	headers["RequestId"] = uuid.String()
 	headers["Request-Module"] = "Naming"
 	headers["Content-Type"] = "application/x-www-form-urlencoded;charset=utf-8"
+	for k, v := range extra {
+		headers[k] = v
+	}
 	query, bodyData := url.Values{}, url.Values{}
 	if params != nil {
 		query = params.Parse()
@@ -75,10 +88,10 @@ func (c *httpClient) api(method, apiURI string, params, body *paramMap) ([]byte,
 	if body != nil {
 		bodyData = body.Parse()
 	}
-	if c.accessToken != "" {
-		query.Set(constant.AccessToken, c.accessToken)
+	if token := c.token(); token != "" {
+		query.Set(constant.AccessToken, token)
 	}
-	return c.do(c.client, method, c.addr+c.contextPath+apiURI, headers, query, bodyData)
+	return c.request(ctx, c.client, method, apiURI, headers, query, bodyData)
 }
 
 func di(method, target string, header map[string]string, body url.Values, err ...error) []interface{} {
@@ -96,16 +109,56 @@ func di(method, target string, header map[string]string, body url.Values, err ..
 	return ps
 }
 
-func (c *httpClient) do(client *http.Client, method, target string, headers map[string]string, params, body url.Values) ([]byte, error) {
+//request 按重试策略重试 requestOnce, 策略可以通过context为单次调用覆盖
+func (c *httpClient) request(ctx context.Context, client *http.Client, method, apiURI string, headers map[string]string, params, body url.Values) ([]byte, error) {
+	policy := retryPolicyFromContext(ctx, c.retryPolicy)
+	for attempt := 1; ; attempt++ {
+		b, err := c.requestOnce(ctx, client, method, apiURI, headers, params, body)
+		if err == nil || policy == nil || attempt >= policy.MaxAttempts || ctx.Err() != nil || !policy.retryable(method, err) {
+			return b, err
+		}
+		d := policy.backoff(attempt)
+		c.log.Warn("httpClientRequest(retry)", apiURI, attempt, d, err)
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(d):
+		}
+	}
+}
+
+//requestOnce 依次尝试可用节点, 连接错误或5xx时拉黑当前节点并在下一个节点重试
+func (c *httpClient) requestOnce(ctx context.Context, client *http.Client, method, apiURI string, headers map[string]string, params, body url.Values) ([]byte, error) {
+	var b []byte
+	var err error
+	l := c.servers.size()
+	if l == 0 {
+		return nil, errNoServerAddr
+	}
+	for i := 0; i < l; i++ {
+		srv := c.servers.pick()
+		b, err = c.do(ctx, client, method, srv.url(apiURI), headers, params, body)
+		//调用方取消或超时不拉黑节点
+		if err == nil || ctx.Err() != nil || !isFailoverError(err) {
+			return b, err
+		}
+		c.log.Warn("httpClientRequest(failover)", srv.addr, err)
+		c.servers.markFailed(srv)
+	}
+	return nil, err
+}
+
+func (c *httpClient) do(ctx context.Context, client *http.Client, method, target string, headers map[string]string, params, body url.Values) ([]byte, error) {
+	endpoint := method + " " + target
 	if len(params) > 0 {
 		target += "?" + params.Encode()
 	}
 	var req *http.Request
 	var err error
 	if len(body) > 0 {
-		req, err = http.NewRequest(method, target, strings.NewReader(body.Encode()))
+		req, err = http.NewRequestWithContext(ctx, method, target, strings.NewReader(body.Encode()))
 	} else {
-		req, err = http.NewRequest(method, target, nil)
+		req, err = http.NewRequestWithContext(ctx, method, target, nil)
 	}
 	if err != nil {
 		c.log.Error("httpClientDo(NewRequest)", di(method, target, headers, body, err)...)
@@ -129,7 +182,7 @@ func (c *httpClient) do(client *http.Client, method, target string, headers map[
 		return nil, err
 	}
 	if resp.StatusCode != http.StatusOK {
-		err := errors.New(string(b))
+		err := newStatusError(resp.StatusCode, endpoint, headers["RequestId"], b)
 		c.log.Error("httpClientDo(statusCode)", di(method, target, headers, body, err)...)
 		return nil, err
 	}
@@ -139,31 +192,64 @@ func (c *httpClient) do(client *http.Client, method, target string, headers map[
 	return b, nil
 }
 
-func (c *httpClient) refreshLogin() {
+//fetchServerList 从地址服务器获取节点列表
+func (c *httpClient) fetchServerList() error {
+	b, err := c.do(context.Background(), c.client, http.MethodGet, c.endpoint, map[string]string{"User-Agent": constant.ClientVersion}, nil, nil)
+	if err != nil {
+		return err
+	}
+	servers := parseEndpointServers(b, constant.DefaultContextPath)
+	if len(servers) == 0 {
+		return errors.New("endpoint returned empty server list")
+	}
+	if c.servers.update(servers) {
+		c.log.Info("server list changed", string(b))
+	}
+	return nil
+}
+
+func (c *httpClient) refreshServerList(ctx context.Context, interval time.Duration) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+		if err := c.fetchServerList(); err != nil {
+			c.log.Error("refreshServerList", err)
+		}
+	}
+}
+
+func (c *httpClient) refreshLogin(ctx context.Context) {
 	if !c.loginExit {
 		return
 	}
 	for {
-		if c.accessTokenTTL == 0 {
-			if err := c.login(); err != nil {
+		if c.tokenTTL() == 0 {
+			if err := c.login(ctx); err != nil {
 				c.loginExit = true
 				return
 			}
 		}
-		<-time.After(time.Duration(c.accessTokenTTL) * time.Second * 9 / 10)
-		if err := c.login(); err != nil {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Duration(c.tokenTTL()) * time.Second * 9 / 10):
+		}
+		if err := c.login(ctx); err != nil {
 			c.loginExit = true
 			return
 		}
 	}
 }
 
-func (c *httpClient) login() error {
+func (c *httpClient) login(ctx context.Context) error {
 	params := url.Values{}
 	params.Set("username", c.username)
 	body := url.Values{}
 	body.Set("password", c.password)
-	b, err := c.do(c.client, http.MethodPost, c.addr+c.contextPath+constant.APILoginPath, map[string]string{"Content-Type": "application/x-www-form-urlencoded"}, params, body)
+	b, err := c.request(ctx, c.client, http.MethodPost, constant.APILoginPath, map[string]string{"Content-Type": "application/x-www-form-urlencoded"}, params, body)
 	if err != nil {
 		return err
 	}
@@ -178,8 +264,23 @@ func (c *httpClient) login() error {
 	if accessTokenTTL == 0 {
 		return errors.New("accessTokenTTL is empty")
 	}
+	c.tokenLock.Lock()
 	c.accessToken = accessToken
 	c.accessTokenTTL = accessTokenTTL
 	c.lastRefreshTime = time.Now()
+	c.tokenLock.Unlock()
 	return nil
 }
+
+//token 当前的accessToken, 可能被心跳或配置监听的重新登录并发修改
+func (c *httpClient) token() string {
+	c.tokenLock.RLock()
+	defer c.tokenLock.RUnlock()
+	return c.accessToken
+}
+
+func (c *httpClient) tokenTTL() int64 {
+	c.tokenLock.RLock()
+	defer c.tokenLock.RUnlock()
+	return c.accessTokenTTL
+}
@@ -0,0 +1,153 @@
+package nacos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/magicdvd/nacos-client/constant"
+)
+
+var ErrNoAvailableInstance = errors.New("no available instance")
+
+//InstanceSelector 从候选实例中选择一个, key 为服务的缓存key, 用于区分不同服务的选择状态
+type InstanceSelector interface {
+	Select(key string, instances []*Instance) *Instance
+}
+
+type weightedRandomSelector struct {
+	lock sync.Mutex
+	r    *rand.Rand
+}
+
+//NewWeightedRandomSelector 按权重随机选择
+func NewWeightedRandomSelector() InstanceSelector {
+	return &weightedRandomSelector{
+		r: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+func (c *weightedRandomSelector) Select(key string, instances []*Instance) *Instance {
+	if len(instances) == 0 {
+		return nil
+	}
+	var total float64
+	for _, v := range instances {
+		total += v.Weight
+	}
+	c.lock.Lock()
+	n := c.r.Float64() * total
+	c.lock.Unlock()
+	for _, v := range instances {
+		n -= v.Weight
+		if n < 0 {
+			return v
+		}
+	}
+	return instances[len(instances)-1]
+}
+
+type smoothWeightedRoundRobinSelector struct {
+	lock   sync.Mutex
+	states map[string]map[string]float64
+}
+
+//NewSmoothWeightedRoundRobinSelector 平滑加权轮询(同nginx), 权重高的实例不会连续被选中
+func NewSmoothWeightedRoundRobinSelector() InstanceSelector {
+	return &smoothWeightedRoundRobinSelector{
+		states: make(map[string]map[string]float64),
+	}
+}
+
+func (c *smoothWeightedRoundRobinSelector) Select(key string, instances []*Instance) *Instance {
+	if len(instances) == 0 {
+		return nil
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	old := c.states[key]
+	//只保留当前实例的状态, 下线的实例自动清除
+	current := make(map[string]float64, len(instances))
+	var total float64
+	var best *Instance
+	var bestKey string
+	for _, v := range instances {
+		k := fmt.Sprintf("%s:%d", v.Ip, v.Port)
+		w := old[k] + v.Weight
+		current[k] = w
+		total += v.Weight
+		if best == nil || w > current[bestKey] {
+			best, bestKey = v, k
+		}
+	}
+	current[bestKey] -= total
+	c.states[key] = current
+	return best
+}
+
+func (c *ServiceClient) SelectInstances(serviceName string, healthy bool, params ...Param) ([]*Instance, error) {
+	return c.SelectInstancesWithContext(context.Background(), serviceName, healthy, params...)
+}
+
+//SelectInstancesWithContext 返回健康状态为 healthy 且已启用, 权重大于0的实例, 可以用 ParamInstanceFilter 进一步过滤
+//从服务端获取包含不健康实例的完整列表, 在客户端按健康状态过滤
+func (c *ServiceClient) SelectInstancesWithContext(ctx context.Context, serviceName string, healthy bool, params ...Param) ([]*Instance, error) {
+	query := newParamMap()
+	query.Set(params...)
+	params = append(params[:len(params):len(params)], ParamHealthy(false))
+	svc, err := c.GetServiceWithContext(ctx, serviceName, true, params...)
+	if err != nil {
+		return nil, err
+	}
+	instances := make([]*Instance, 0, len(svc.Instances))
+	for _, v := range svc.Instances {
+		if v.Healthy != healthy || !v.Enable || v.Weight <= 0 {
+			continue
+		}
+		if query.filter != nil && !query.filter(v) {
+			continue
+		}
+		instances = append(instances, v)
+	}
+	return instances, nil
+}
+
+func (c *ServiceClient) SelectOneHealthyInstance(serviceName string, params ...Param) (*Instance, error) {
+	return c.SelectOneHealthyInstanceWithContext(context.Background(), serviceName, params...)
+}
+
+//SelectOneHealthyInstanceWithContext 使用 InstanceSelector 选择一个健康实例
+//设置了 ParamClusterName 时优先选择同集群的实例, 同集群没有可用实例时再从全部实例中选择
+func (c *ServiceClient) SelectOneHealthyInstanceWithContext(ctx context.Context, serviceName string, params ...Param) (*Instance, error) {
+	query := newParamMap()
+	query.Set(
+		paramServiceName(serviceName),
+		ParamGroupName(constant.DefaultGroupName),
+		ParamNameSpaceID(c.opts.defautNameSpaceID),
+	)
+	query.Set(params...)
+	instances, err := c.SelectInstancesWithContext(ctx, serviceName, true, params...)
+	if err != nil {
+		return nil, err
+	}
+	key := query.nameSpaceID + "##" + query.GetGrouppedServiceName()
+	if query.clusterName != "" {
+		same := make([]*Instance, 0, len(instances))
+		for _, v := range instances {
+			if v.ClusterName == query.clusterName {
+				same = append(same, v)
+			}
+		}
+		if len(same) > 0 {
+			instances = same
+			key += "@@" + query.clusterName
+		}
+	}
+	if inst := c.opts.selector.Select(key, instances); inst != nil {
+		return inst, nil
+	}
+	return nil, ErrNoAvailableInstance
+}
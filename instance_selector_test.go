@@ -0,0 +1,127 @@
+package nacos
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func Test_smoothWeightedRoundRobinSelector(t *testing.T) {
+	instances := []*Instance{
+		{Ip: "a", Port: 1, Weight: 5},
+		{Ip: "b", Port: 1, Weight: 1},
+		{Ip: "c", Port: 1, Weight: 1},
+	}
+	s := NewSmoothWeightedRoundRobinSelector()
+	var seq []string
+	for i := 0; i < 7; i++ {
+		seq = append(seq, s.Select("svc", instances).Ip)
+	}
+	if strings.Join(seq, "") != "aabacaa" {
+		t.Error("unexpected sequence", seq)
+	}
+}
+
+func Test_weightedRandomSelector(t *testing.T) {
+	instances := []*Instance{
+		{Ip: "a", Port: 1, Weight: 0.001},
+		{Ip: "b", Port: 1, Weight: 100},
+	}
+	s := NewWeightedRandomSelector()
+	count := 0
+	for i := 0; i < 1000; i++ {
+		if s.Select("svc", instances).Ip == "b" {
+			count++
+		}
+	}
+	if count < 950 {
+		t.Error("weights not honoured", count)
+	}
+	if s.Select("svc", nil) != nil {
+		t.Error("expected nil for empty instances")
+	}
+}
+
+func newSelectorTestClient(t *testing.T) ServiceCmdable {
+	fake := NewFakeTransport()
+	for _, v := range []*InstanceRequest{
+		{IP: "10.0.0.1", ClusterName: "A", Weight: 1, Enabled: true, Healthy: true},
+		{IP: "10.0.0.2", ClusterName: "B", Weight: 1, Enabled: true, Healthy: false},
+		{IP: "10.0.0.3", ClusterName: "A", Weight: 1, Enabled: false, Healthy: true},
+		{IP: "10.0.0.4", ClusterName: "A", Weight: 0, Enabled: true, Healthy: true},
+		{IP: "10.0.0.5", ClusterName: "B", Weight: 2, Enabled: true, Healthy: true},
+	} {
+		v.NameSpaceID, v.GroupName, v.ServiceName, v.Port, v.Ephemeral = "public", "DEFAULT_GROUP", "svc", 80, true
+		if err := fake.RegisterInstance(context.Background(), v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a, err := NewServiceClient("", WithTransport(fake), LogLevel("error"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func instanceIPs(instances []*Instance) string {
+	ips := make([]string, 0, len(instances))
+	for _, v := range instances {
+		ips = append(ips, v.Ip)
+	}
+	sort.Strings(ips)
+	return strings.Join(ips, ",")
+}
+
+func TestSelectInstances(t *testing.T) {
+	a := newSelectorTestClient(t)
+	defer a.Close(context.Background())
+	//只有健康实例的查询结果不能影响包含不健康实例的查询
+	if svc, err := a.GetService("svc", true); err != nil || len(svc.Instances) != 4 {
+		t.Fatal("unexpected healthy service", svc, err)
+	}
+	for _, c := range []struct {
+		healthy bool
+		params  []Param
+		want    string
+	}{
+		{true, nil, "10.0.0.1,10.0.0.5"},
+		{false, nil, "10.0.0.2"},
+		{true, []Param{ParamInstanceFilter(func(v *Instance) bool { return v.ClusterName == "B" })}, "10.0.0.5"},
+		{false, []Param{ParamInstanceFilter(func(v *Instance) bool { return v.ClusterName == "A" })}, ""},
+	} {
+		instances, err := a.SelectInstances("svc", c.healthy, c.params...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := instanceIPs(instances); got != c.want {
+			t.Errorf("SelectInstances(healthy=%v) = %s, want %s", c.healthy, got, c.want)
+		}
+	}
+}
+
+func TestSelectOneHealthyInstance(t *testing.T) {
+	a := newSelectorTestClient(t)
+	defer a.Close(context.Background())
+	for i := 0; i < 10; i++ {
+		inst, err := a.SelectOneHealthyInstance("svc", ParamClusterName("A"))
+		if err != nil || inst.Ip != "10.0.0.1" {
+			t.Fatal("expected instance in same cluster", inst, err)
+		}
+	}
+	//同集群没有可用实例时从全部健康实例中选择
+	seen := make(map[string]bool)
+	for i := 0; i < 50; i++ {
+		inst, err := a.SelectOneHealthyInstance("svc", ParamClusterName("C"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen[inst.Ip] = true
+	}
+	if len(seen) != 2 || !seen["10.0.0.1"] || !seen["10.0.0.5"] {
+		t.Error("expected fallback to all healthy instances", seen)
+	}
+	if _, err := a.SelectOneHealthyInstance("missing"); err != ErrNoAvailableInstance {
+		t.Error("expected no available instance", err)
+	}
+}
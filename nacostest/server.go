@@ -0,0 +1,1167 @@
+//Package nacostest 内存中的Nacos 1.x http服务端, 用于不依赖真实Nacos的集成测试
+//
+//	srv := nacostest.NewServer()
+//	defer srv.Close()
+//	client, _ := nacos.NewServiceClient(srv.URL, nacos.DiscoveryIP("127.0.0.1"))
+package nacostest
+
+import (
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/magicdvd/nacos-client/constant"
+)
+
+const (
+	splitChar1 = string(byte(1))
+	splitChar2 = string(byte(2))
+)
+
+//Instance 服务端保存的实例
+type Instance struct {
+	InstanceID  string            `json:"instanceId"`
+	IP          string            `json:"ip"`
+	Port        uint64            `json:"port"`
+	Weight      float64           `json:"weight"`
+	Healthy     bool              `json:"healthy"`
+	Enabled     bool              `json:"enabled"`
+	Ephemeral   bool              `json:"ephemeral"`
+	ClusterName string            `json:"clusterName"`
+	ServiceName string            `json:"serviceName"`
+	Metadata    map[string]string `json:"metadata"`
+	Valid       bool              `json:"valid"`
+	Marked      bool              `json:"marked"`
+	lastBeat    time.Time
+}
+
+//Namespace 命名空间, 公共命名空间的ID为空
+type Namespace struct {
+	ID          string `json:"namespace"`
+	ShowName    string `json:"namespaceShowName"`
+	Desc        string `json:"namespaceDesc"`
+	Quota       int    `json:"quota"`
+	ConfigCount int    `json:"configCount"`
+	Type        int    `json:"type"`
+}
+
+//configHistory 配置的历史记录, nid 从1开始
+type configHistory struct {
+	nid     int64
+	dataID  string
+	group   string
+	tenant  string
+	content string
+	opType  string
+	time    time.Time
+}
+
+//json 1.x的格式, opType 与Nacos一样补齐空格, content 只在详情中返回
+func (h *configHistory) json(detail bool) map[string]interface{} {
+	t := h.time.UTC().Format("2006-01-02T15:04:05.000+0000")
+	v := map[string]interface{}{
+		"id":               strconv.FormatInt(h.nid, 10),
+		"lastId":           -1,
+		"dataId":           h.dataID,
+		"group":            h.group,
+		"tenant":           h.tenant,
+		"appName":          "",
+		"md5":              nil,
+		"content":          nil,
+		"srcIp":            "127.0.0.1",
+		"srcUser":          nil,
+		"opType":           fmt.Sprintf("%-10s", h.opType),
+		"createdTime":      t,
+		"lastModifiedTime": t,
+	}
+	if detail {
+		v["md5"] = md5string(h.content)
+		v["content"] = h.content
+	}
+	return v
+}
+
+//serviceDefinition 通过 /v1/ns/service 创建或修改的服务属性
+type serviceDefinition struct {
+	protectThreshold float64
+	metadata         map[string]string
+	selector         json.RawMessage
+}
+
+type subscriber struct {
+	addr     *net.UDPAddr
+	clusters string
+}
+
+//Server 模拟的Nacos服务端, 状态只保存在内存中
+type Server struct {
+	//URL 客户端使用的地址, 包含 /nacos
+	URL string
+
+	srv              *httptest.Server
+	conn             *net.UDPConn
+	done             chan struct{}
+	wg               sync.WaitGroup
+	username         string
+	password         string
+	tokenTTL         time.Duration
+	beatInterval     time.Duration
+	unhealthyTimeout time.Duration
+	deleteTimeout    time.Duration
+	version          string
+
+	lock        sync.Mutex
+	tokens      map[string]time.Time
+	services    map[string]map[string]*Instance
+	subscribers map[string]map[string]*subscriber
+	clusters    map[string]string
+	definitions map[string]*serviceDefinition
+	namespaces  []*Namespace
+	configs     map[string]string
+	history     []*configHistory
+	changed     chan struct{}
+}
+
+type Option interface {
+	apply(*Server)
+}
+
+type funcOption struct {
+	f func(*Server)
+}
+
+func (fo *funcOption) apply(s *Server) {
+	fo.f(s)
+}
+
+func newFuncOption(f func(*Server)) *funcOption {
+	return &funcOption{
+		f: f,
+	}
+}
+
+//Auth 开启鉴权, 除登录外的请求都需要有效的accessToken
+func Auth(username, password string) Option {
+	return newFuncOption(func(s *Server) {
+		s.username = username
+		s.password = password
+	})
+}
+
+//TokenTTL accessToken的有效期 [5h]
+func TokenTTL(d time.Duration) Option {
+	return newFuncOption(func(s *Server) {
+		s.tokenTTL = d
+	})
+}
+
+//BeatInterval 返回给客户端的心跳间隔 [5s]
+func BeatInterval(d time.Duration) Option {
+	return newFuncOption(func(s *Server) {
+		s.beatInterval = d
+	})
+}
+
+//BeatTimeout 临时实例超过 unhealthy 没有心跳标记为不健康, 超过 delete 删除 [15s, 30s]
+func BeatTimeout(unhealthy, delete time.Duration) Option {
+	return newFuncOption(func(s *Server) {
+		s.unhealthyTimeout = unhealthy
+		s.deleteTimeout = delete
+	})
+}
+
+//ServerVersion /v1/console/server/state 返回的版本, 低于2.0时与Nacos 1.x一样忽略casMd5 [2.0.3]
+func ServerVersion(v string) Option {
+	return newFuncOption(func(s *Server) {
+		s.version = v
+	})
+}
+
+func NewServer(options ...Option) *Server {
+	s := &Server{
+		done:             make(chan struct{}),
+		tokenTTL:         5 * time.Hour,
+		beatInterval:     5 * time.Second,
+		unhealthyTimeout: 15 * time.Second,
+		deleteTimeout:    30 * time.Second,
+		version:          "2.0.3",
+		tokens:           make(map[string]time.Time),
+		services:         make(map[string]map[string]*Instance),
+		subscribers:      make(map[string]map[string]*subscriber),
+		clusters:         make(map[string]string),
+		definitions:      make(map[string]*serviceDefinition),
+		namespaces:       []*Namespace{{ShowName: "public", Quota: 200}},
+		configs:          make(map[string]string),
+		changed:          make(chan struct{}),
+	}
+	for _, op := range options {
+		op.apply(s)
+	}
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		panic(fmt.Sprintf("nacostest: failed to listen udp: %v", err))
+	}
+	s.conn = conn
+	mux := http.NewServeMux()
+	mux.HandleFunc(constant.DefaultContextPath+constant.APILoginPath, s.handleLogin)
+	mux.HandleFunc(constant.DefaultContextPath+constant.APIInstance, s.auth(s.handleInstance))
+	mux.HandleFunc(constant.DefaultContextPath+constant.APICluster, s.auth(s.handleCluster))
+	mux.HandleFunc(constant.DefaultContextPath+constant.APIService, s.auth(s.handleService))
+	mux.HandleFunc(constant.DefaultContextPath+constant.APIServiceList, s.auth(s.handleServiceList))
+	mux.HandleFunc(constant.DefaultContextPath+constant.APIInstanceList, s.auth(s.handleInstanceList))
+	mux.HandleFunc(constant.DefaultContextPath+constant.APIInstanceBeat, s.auth(s.handleBeat))
+	mux.HandleFunc(constant.DefaultContextPath+constant.APINamespaces, s.auth(s.handleNamespaces))
+	mux.HandleFunc(constant.DefaultContextPath+constant.APIServerState, s.handleServerState)
+	mux.HandleFunc(constant.DefaultContextPath+constant.APIConfig, s.auth(s.handleConfig))
+	mux.HandleFunc(constant.DefaultContextPath+constant.APIConfigHistory, s.auth(s.handleConfigHistory))
+	mux.HandleFunc(constant.DefaultContextPath+constant.APIConfigListen, s.auth(s.handleConfigListen))
+	s.srv = httptest.NewServer(mux)
+	s.URL = s.srv.URL + constant.DefaultContextPath
+	s.wg.Add(2)
+	go func() {
+		defer s.wg.Done()
+		s.checkBeats()
+	}()
+	go func() {
+		defer s.wg.Done()
+		s.drainAcks()
+	}()
+	return s
+}
+
+//Close 关闭http和udp, 未返回的长轮询会立即结束
+func (s *Server) Close() {
+	close(s.done)
+	s.srv.CloseClientConnections()
+	s.srv.Close()
+	s.conn.Close()
+	s.wg.Wait()
+}
+
+//Instances 返回服务的所有实例, serviceName 为 group@@name
+func (s *Server) Instances(nameSpaceID string, serviceName string) []Instance {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	instances := make([]Instance, 0)
+	for _, v := range s.sortedInstances(nameSpaceID + "##" + serviceName) {
+		instances = append(instances, *v)
+	}
+	return instances
+}
+
+//HealthChecker 返回集群健康检查配置的json, serviceName 为 group@@name
+func (s *Server) HealthChecker(nameSpaceID string, serviceName string, cluster string) (string, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	h, ok := s.clusters[nameSpaceID+"##"+serviceName+"##"+cluster]
+	return h, ok
+}
+
+//Config 返回服务端当前的配置内容
+func (s *Server) Config(dataID, group, tenant string) (string, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	content, ok := s.configs[configKey(dataID, group, tenant)]
+	return content, ok
+}
+
+//SetConfig 直接修改服务端的配置, 会记录历史并唤醒监听该配置的长轮询
+func (s *Server) SetConfig(dataID, group, tenant, content string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.publishLocked(dataID, group, tenant, content)
+	s.wakeLocked()
+}
+
+//publishLocked 与Nacos一样, 新增时记录新内容, 修改时记录变更前的内容
+func (s *Server) publishLocked(dataID, group, tenant, content string) {
+	key := configKey(dataID, group, tenant)
+	if old, ok := s.configs[key]; ok {
+		s.recordHistoryLocked(dataID, group, tenant, old, "U")
+	} else {
+		s.recordHistoryLocked(dataID, group, tenant, content, "I")
+	}
+	s.configs[key] = content
+}
+
+func (s *Server) recordHistoryLocked(dataID, group, tenant, content, opType string) {
+	s.history = append(s.history, &configHistory{
+		nid:     int64(len(s.history) + 1),
+		dataID:  dataID,
+		group:   group,
+		tenant:  tenant,
+		content: content,
+		opType:  opType,
+		time:    time.Now(),
+	})
+}
+
+func configKey(dataID, group, tenant string) string {
+	return dataID + splitChar2 + group + splitChar2 + tenant
+}
+
+func md5string(s string) string {
+	return fmt.Sprintf("%x", md5.Sum([]byte(s)))
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	b, _ := json.Marshal(v)
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
+
+func writeError(w http.ResponseWriter, code int, msg string) {
+	w.WriteHeader(code)
+	_, _ = w.Write([]byte(msg))
+}
+
+//param 同时支持query和form参数
+func param(r *http.Request, key string, def string) string {
+	if v := r.Form.Get(key); v != "" {
+		return v
+	}
+	return def
+}
+
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	_ = r.ParseForm()
+	if s.username == "" || r.Form.Get("username") != s.username || r.Form.Get("password") != s.password {
+		writeError(w, http.StatusForbidden, "unknown user!")
+		return
+	}
+	token := uuid.New().String()
+	s.lock.Lock()
+	s.tokens[token] = time.Now().Add(s.tokenTTL)
+	s.lock.Unlock()
+	writeJSON(w, map[string]interface{}{
+		constant.AccessToken:    token,
+		constant.AccessTokenTTL: int64(s.tokenTTL / time.Second),
+		"globalAdmin":           true,
+	})
+}
+
+func (s *Server) auth(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		if s.username != "" {
+			s.lock.Lock()
+			expire, ok := s.tokens[r.Form.Get(constant.AccessToken)]
+			s.lock.Unlock()
+			if !ok || time.Now().After(expire) {
+				writeError(w, http.StatusForbidden, "token invalid!")
+				return
+			}
+		}
+		h(w, r)
+	}
+}
+
+//serviceKey 返回 namespace##group@@name 格式的服务key
+func serviceKey(r *http.Request) (string, string, string, error) {
+	nameSpaceID := param(r, "namespaceId", constant.DefaultNameSpaceID)
+	serviceName := r.Form.Get("serviceName")
+	if serviceName == "" {
+		return "", "", "", fmt.Errorf("Param 'serviceName' is required.")
+	}
+	if !strings.Contains(serviceName, "@@") {
+		serviceName = param(r, "groupName", constant.DefaultGroupName) + "@@" + serviceName
+	}
+	return nameSpaceID + "##" + serviceName, nameSpaceID, serviceName, nil
+}
+
+func instanceID(ip string, port uint64, cluster string, serviceName string) string {
+	return fmt.Sprintf("%s#%d#%s#%s", ip, port, cluster, serviceName)
+}
+
+func (s *Server) handleInstance(w http.ResponseWriter, r *http.Request) {
+	key, nameSpaceID, serviceName, err := serviceKey(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	ip := r.Form.Get("ip")
+	port, err := strconv.ParseUint(r.Form.Get("port"), 10, 64)
+	if ip == "" || err != nil {
+		writeError(w, http.StatusBadRequest, "Param 'ip' and 'port' are required.")
+		return
+	}
+	cluster := param(r, "clusterName", constant.DefaultClusterName)
+	id := instanceID(ip, port, cluster, serviceName)
+	switch r.Method {
+	case http.MethodGet:
+		s.lock.Lock()
+		inst, ok := s.services[key][id]
+		var detail map[string]interface{}
+		if ok {
+			detail = map[string]interface{}{
+				"instanceId":  inst.InstanceID,
+				"ip":          inst.IP,
+				"port":        inst.Port,
+				"service":     inst.ServiceName,
+				"clusterName": inst.ClusterName,
+				"weight":      inst.Weight,
+				"healthy":     inst.Healthy,
+				"metadata":    inst.Metadata,
+			}
+		}
+		s.lock.Unlock()
+		if !ok {
+			writeError(w, http.StatusNotFound, "no matched ip found!")
+			return
+		}
+		writeJSON(w, detail)
+		return
+	case http.MethodPost:
+		inst := &Instance{
+			InstanceID:  id,
+			IP:          ip,
+			Port:        port,
+			Weight:      1,
+			Healthy:     param(r, "healthy", "true") == "true",
+			Enabled:     param(r, "enabled", "true") == "true",
+			Ephemeral:   param(r, "ephemeral", "true") == "true",
+			ClusterName: cluster,
+			ServiceName: serviceName,
+			Metadata:    map[string]string{},
+			lastBeat:    time.Now(),
+		}
+		inst.Valid = inst.Healthy
+		if err = updateInstance(r, inst); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		s.lock.Lock()
+		if _, ok := s.services[key]; !ok {
+			s.services[key] = make(map[string]*Instance)
+		}
+		s.services[key][id] = inst
+		s.lock.Unlock()
+	case http.MethodPut:
+		s.lock.Lock()
+		inst, ok := s.services[key][id]
+		if ok {
+			update := *inst
+			if err = updateInstance(r, &update); err == nil {
+				*inst = update
+			}
+		}
+		s.lock.Unlock()
+		if !ok {
+			writeError(w, http.StatusBadRequest, "instance not exist: "+id)
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	case http.MethodDelete:
+		//与Nacos一样, ephemeral 与注册时不一致时不会删除
+		s.lock.Lock()
+		if v, ok := s.services[key][id]; ok && v.Ephemeral == (param(r, "ephemeral", "true") == "true") {
+			delete(s.services[key], id)
+		}
+		s.lock.Unlock()
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	s.push(nameSpaceID, serviceName)
+	_, _ = w.Write([]byte("ok"))
+}
+
+//handleServiceList 按服务名排序分页, 不支持选择器
+func (s *Server) handleServiceList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	pageNo, err := strconv.Atoi(r.Form.Get("pageNo"))
+	if err != nil || pageNo < 1 {
+		writeError(w, http.StatusBadRequest, "Param 'pageNo' is invalid.")
+		return
+	}
+	pageSize, err := strconv.Atoi(r.Form.Get("pageSize"))
+	if err != nil || pageSize < 1 {
+		writeError(w, http.StatusBadRequest, "Param 'pageSize' is invalid.")
+		return
+	}
+	prefix := param(r, "namespaceId", constant.DefaultNameSpaceID) + "##" + param(r, "groupName", constant.DefaultGroupName) + "@@"
+	names := make([]string, 0)
+	s.lock.Lock()
+	for k := range s.services {
+		if strings.HasPrefix(k, prefix) {
+			names = append(names, strings.TrimPrefix(k, prefix))
+		}
+	}
+	s.lock.Unlock()
+	sort.Strings(names)
+	doms := make([]string, 0)
+	if start := (pageNo - 1) * pageSize; start < len(names) {
+		end := start + pageSize
+		if end > len(names) {
+			end = len(names)
+		}
+		doms = names[start:end]
+	}
+	writeJSON(w, map[string]interface{}{"count": len(names), "doms": doms})
+}
+
+func (s *Server) handleService(w http.ResponseWriter, r *http.Request) {
+	key, nameSpaceID, serviceName, err := serviceKey(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		s.serviceDetail(w, key, nameSpaceID, serviceName)
+		return
+	case http.MethodPost, http.MethodPut:
+		def := &serviceDefinition{metadata: parseMetadata(r.Form.Get("metadata")), selector: json.RawMessage(`{"type":"none"}`)}
+		if v := r.Form.Get("protectThreshold"); v != "" {
+			if def.protectThreshold, err = strconv.ParseFloat(v, 64); err != nil {
+				writeError(w, http.StatusBadRequest, "Param 'protectThreshold' is invalid.")
+				return
+			}
+		}
+		if v := r.Form.Get("selector"); v != "" {
+			if !json.Valid([]byte(v)) {
+				writeError(w, http.StatusBadRequest, "Param 'selector' is invalid.")
+				return
+			}
+			def.selector = json.RawMessage(v)
+		}
+		s.lock.Lock()
+		_, ok := s.services[key]
+		if ok == (r.Method == http.MethodPost) {
+			s.lock.Unlock()
+			if ok {
+				writeError(w, http.StatusBadRequest, "specified service already exists, serviceName : "+serviceName)
+			} else {
+				writeError(w, http.StatusBadRequest, "service not found: "+serviceName)
+			}
+			return
+		}
+		if !ok {
+			s.services[key] = make(map[string]*Instance)
+		}
+		s.definitions[key] = def
+		s.lock.Unlock()
+	case http.MethodDelete:
+		s.lock.Lock()
+		instances, ok := s.services[key]
+		if ok && len(instances) == 0 {
+			delete(s.services, key)
+			delete(s.definitions, key)
+			for k := range s.clusters {
+				if strings.HasPrefix(k, key+"##") {
+					delete(s.clusters, k)
+				}
+			}
+		}
+		s.lock.Unlock()
+		if !ok {
+			writeError(w, http.StatusBadRequest, "specified service not exist, serviceName : "+serviceName)
+			return
+		}
+		if len(instances) > 0 {
+			writeError(w, http.StatusBadRequest, "specified service has instances, serviceName : "+serviceName)
+			return
+		}
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	_, _ = w.Write([]byte("ok"))
+}
+
+func (s *Server) serviceDetail(w http.ResponseWriter, key string, nameSpaceID string, serviceName string) {
+	s.lock.Lock()
+	instances, ok := s.services[key]
+	def := s.definitions[key]
+	clusters := make(map[string]map[string]interface{})
+	for _, v := range instances {
+		clusters[v.ClusterName] = map[string]interface{}{"name": v.ClusterName, "healthChecker": json.RawMessage(`{"type":"TCP"}`), "metadata": map[string]string{}}
+	}
+	for k, v := range s.clusters {
+		if name := strings.TrimPrefix(k, key+"##"); name != k {
+			clusters[name] = map[string]interface{}{"name": name, "healthChecker": json.RawMessage(v), "metadata": map[string]string{}}
+		}
+	}
+	s.lock.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "service "+serviceName+" is not found!")
+		return
+	}
+	if def == nil {
+		def = &serviceDefinition{metadata: map[string]string{}, selector: json.RawMessage(`{"type":"none"}`)}
+	}
+	names := make([]string, 0, len(clusters))
+	for k := range clusters {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	list := make([]map[string]interface{}, 0, len(names))
+	for _, v := range names {
+		list = append(list, clusters[v])
+	}
+	t := strings.SplitN(serviceName, "@@", 2)
+	writeJSON(w, map[string]interface{}{
+		"name":             t[1],
+		"groupName":        t[0],
+		"namespaceId":      nameSpaceID,
+		"protectThreshold": def.protectThreshold,
+		"metadata":         def.metadata,
+		"selector":         def.selector,
+		"clusters":         list,
+	})
+}
+
+//handleServerState 服务端状态, 不需要鉴权
+func (s *Server) handleServerState(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	writeJSON(w, map[string]interface{}{"version": s.version, "standalone_mode": "standalone", "function_mode": nil})
+}
+
+//handleNamespaces 与Nacos 1.x控制台接口一样, 修改成功返回true, 失败返回false
+func (s *Server) handleNamespaces(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	index := func(id string) int {
+		for i, v := range s.namespaces {
+			if v.ID == id {
+				return i
+			}
+		}
+		return -1
+	}
+	ok := false
+	switch r.Method {
+	case http.MethodGet:
+		data := make([]Namespace, 0, len(s.namespaces))
+		for _, v := range s.namespaces {
+			ns := *v
+			for k := range s.configs {
+				if strings.SplitN(k, splitChar2, 3)[2] == ns.ID {
+					ns.ConfigCount++
+				}
+			}
+			data = append(data, ns)
+		}
+		writeJSON(w, map[string]interface{}{"code": 200, "message": nil, "data": data})
+		return
+	case http.MethodPost:
+		id := r.Form.Get("customNamespaceId")
+		if id == "" {
+			id = fmt.Sprintf("namespace-%d", len(s.namespaces))
+		}
+		if ok = index(id) < 0; ok {
+			s.namespaces = append(s.namespaces, &Namespace{ID: id, ShowName: r.Form.Get("namespaceName"), Desc: r.Form.Get("namespaceDesc"), Quota: 200, Type: 2})
+		}
+	case http.MethodPut:
+		id := r.Form.Get("namespace")
+		if i := index(id); i >= 0 && id != "" {
+			s.namespaces[i].ShowName = r.Form.Get("namespaceShowName")
+			s.namespaces[i].Desc = r.Form.Get("namespaceDesc")
+			ok = true
+		}
+	case http.MethodDelete:
+		id := r.Form.Get("namespaceId")
+		if i := index(id); i >= 0 && id != "" {
+			s.namespaces = append(s.namespaces[:i], s.namespaces[i+1:]...)
+			ok = true
+		}
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	_, _ = w.Write([]byte(strconv.FormatBool(ok)))
+}
+
+//handleCluster 只保存健康检查配置, 不会真正检查持久化实例
+func (s *Server) handleCluster(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	key, _, serviceName, err := serviceKey(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	checker := r.Form.Get("healthChecker")
+	if checker == "" {
+		writeError(w, http.StatusBadRequest, "Param 'healthChecker' is required.")
+		return
+	}
+	s.lock.Lock()
+	_, ok := s.services[key]
+	if ok {
+		s.clusters[key+"##"+param(r, "clusterName", constant.DefaultClusterName)] = checker
+	}
+	s.lock.Unlock()
+	if !ok {
+		writeError(w, http.StatusBadRequest, "service not found: "+serviceName)
+		return
+	}
+	_, _ = w.Write([]byte("ok"))
+}
+
+//updateInstance 修改请求中带有的 weight/enabled/metadata
+func updateInstance(r *http.Request, inst *Instance) error {
+	if v := r.Form.Get("weight"); v != "" {
+		weight, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("Param 'weight' is invalid.")
+		}
+		inst.Weight = weight
+	}
+	if v := r.Form.Get("enabled"); v != "" {
+		inst.Enabled = v == "true"
+	}
+	if v := r.Form.Get("metadata"); v != "" {
+		inst.Metadata = parseMetadata(v)
+	}
+	return nil
+}
+
+//parseMetadata 与Nacos一样支持json和 k1=v1,k2=v2 格式
+func parseMetadata(v string) map[string]string {
+	metadata := map[string]string{}
+	if err := json.Unmarshal([]byte(v), &metadata); err != nil {
+		for _, kv := range strings.Split(v, ",") {
+			if t := strings.SplitN(kv, "=", 2); len(t) == 2 {
+				metadata[t[0]] = t[1]
+			}
+		}
+	}
+	return metadata
+}
+
+func (s *Server) sortedInstances(key string) []*Instance {
+	instances := make([]*Instance, 0, len(s.services[key]))
+	for _, v := range s.services[key] {
+		instances = append(instances, v)
+	}
+	sort.Slice(instances, func(i, j int) bool {
+		return instances[i].InstanceID < instances[j].InstanceID
+	})
+	return instances
+}
+
+//serviceJSON 实例列表的返回值, 也用于udp推送
+func (s *Server) serviceJSON(key string, serviceName string, clusters string, healthyOnly bool) []byte {
+	filter := make(map[string]bool)
+	for _, v := range strings.Split(clusters, ",") {
+		if v != "" {
+			filter[v] = true
+		}
+	}
+	hosts := make([]*Instance, 0)
+	for _, v := range s.sortedInstances(key) {
+		if len(filter) > 0 && !filter[v.ClusterName] {
+			continue
+		}
+		if healthyOnly && !v.Healthy {
+			continue
+		}
+		hosts = append(hosts, v)
+	}
+	b, _ := json.Marshal(map[string]interface{}{
+		"name":        serviceName,
+		"clusters":    clusters,
+		"cacheMillis": 10000,
+		"hosts":       hosts,
+		"lastRefTime": time.Now().UnixNano() / int64(time.Millisecond),
+		"checksum":    "",
+		"allIPs":      false,
+		"valid":       true,
+	})
+	return b
+}
+
+func (s *Server) handleInstanceList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	key, _, serviceName, err := serviceKey(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	clusters := r.Form.Get("clusters")
+	//带上udpPort即为订阅, 变更时推送到 clientIP:udpPort
+	if port, _ := strconv.Atoi(r.Form.Get("udpPort")); port > 0 {
+		ip := r.Form.Get("clientIP")
+		if ip == "" {
+			ip, _, _ = net.SplitHostPort(r.RemoteAddr)
+		}
+		if addr, err := net.ResolveUDPAddr("udp", net.JoinHostPort(ip, strconv.Itoa(port))); err == nil {
+			s.lock.Lock()
+			if _, ok := s.subscribers[key]; !ok {
+				s.subscribers[key] = make(map[string]*subscriber)
+			}
+			s.subscribers[key][addr.String()+"#"+clusters] = &subscriber{addr: addr, clusters: clusters}
+			s.lock.Unlock()
+		}
+	}
+	s.lock.Lock()
+	b := s.serviceJSON(key, serviceName, clusters, r.Form.Get("healthyOnly") == "true")
+	s.lock.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
+
+//push 通过udp将服务变更推送给订阅者
+func (s *Server) push(nameSpaceID string, serviceName string) {
+	key := nameSpaceID + "##" + serviceName
+	s.lock.Lock()
+	type message struct {
+		addr *net.UDPAddr
+		b    []byte
+	}
+	messages := make([]message, 0)
+	for _, sub := range s.subscribers[key] {
+		b, _ := json.Marshal(map[string]interface{}{
+			"type":        "dom",
+			"data":        string(s.serviceJSON(key, serviceName, sub.clusters, false)),
+			"lastRefTime": time.Now().UnixNano() / int64(time.Millisecond),
+		})
+		messages = append(messages, message{addr: sub.addr, b: b})
+	}
+	s.lock.Unlock()
+	for _, m := range messages {
+		_, _ = s.conn.WriteToUDP(m.b, m.addr)
+	}
+}
+
+//drainAcks 读取客户端的push-ack
+func (s *Server) drainAcks() {
+	buf := make([]byte, 4096)
+	for {
+		if _, _, err := s.conn.ReadFromUDP(buf); err != nil {
+			select {
+			case <-s.done:
+				return
+			default:
+			}
+		}
+	}
+}
+
+func (s *Server) handleBeat(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	key, nameSpaceID, serviceName, err := serviceKey(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	ip := r.Form.Get("ip")
+	port, _ := strconv.ParseUint(r.Form.Get("port"), 10, 64)
+	cluster := param(r, "clusterName", constant.DefaultClusterName)
+	var beat struct {
+		IP       string                 `json:"ip"`
+		Port     uint64                 `json:"port"`
+		Weight   float64                `json:"weight"`
+		Cluster  string                 `json:"cluster"`
+		Metadata map[string]interface{} `json:"metadata"`
+	}
+	hasBeat := false
+	if v := r.Form.Get("beat"); v != "" {
+		if err = json.Unmarshal([]byte(v), &beat); err != nil {
+			writeError(w, http.StatusBadRequest, "Param 'beat' is invalid.")
+			return
+		}
+		hasBeat = true
+		if ip == "" {
+			ip, port, cluster = beat.IP, beat.Port, beat.Cluster
+		}
+	}
+	id := instanceID(ip, port, cluster, serviceName)
+	resp := map[string]interface{}{
+		constant.ClientBeatInterval: int64(s.beatInterval / time.Millisecond),
+		constant.LightBeatEnabled:   true,
+		constant.Code:               10200,
+	}
+	s.lock.Lock()
+	inst, ok := s.services[key][id]
+	changed := false
+	switch {
+	case ok:
+		inst.lastBeat = time.Now()
+		if !inst.Healthy {
+			inst.Healthy, inst.Valid = true, true
+			changed = true
+		}
+	case hasBeat:
+		//与Nacos 1.x一样, 带完整心跳时自动注册
+		inst = &Instance{
+			InstanceID:  id,
+			IP:          ip,
+			Port:        port,
+			Weight:      beat.Weight,
+			Healthy:     true,
+			Valid:       true,
+			Enabled:     true,
+			Ephemeral:   true,
+			ClusterName: cluster,
+			ServiceName: serviceName,
+			Metadata:    map[string]string{},
+			lastBeat:    time.Now(),
+		}
+		for k, v := range beat.Metadata {
+			inst.Metadata[k] = fmt.Sprint(v)
+		}
+		if _, ok := s.services[key]; !ok {
+			s.services[key] = make(map[string]*Instance)
+		}
+		s.services[key][id] = inst
+		changed = true
+	default:
+		resp[constant.Code] = 20404
+	}
+	s.lock.Unlock()
+	if changed {
+		s.push(nameSpaceID, serviceName)
+	}
+	writeJSON(w, resp)
+}
+
+//checkBeats 临时实例心跳超时后标记为不健康, 再超时后删除
+func (s *Server) checkBeats() {
+	interval := s.unhealthyTimeout / 10
+	if interval > time.Second {
+		interval = time.Second
+	}
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-time.After(interval):
+		}
+		now := time.Now()
+		changed := make(map[string]bool)
+		s.lock.Lock()
+		for key, instances := range s.services {
+			for id, v := range instances {
+				if !v.Ephemeral {
+					continue
+				}
+				since := now.Sub(v.lastBeat)
+				if since > s.deleteTimeout {
+					delete(instances, id)
+					changed[key] = true
+				} else if since > s.unhealthyTimeout && v.Healthy {
+					v.Healthy, v.Valid = false, false
+					changed[key] = true
+				}
+			}
+		}
+		s.lock.Unlock()
+		for key := range changed {
+			t := strings.SplitN(key, "##", 2)
+			s.push(t[0], t[1])
+		}
+	}
+}
+
+func (s *Server) handleConfig(w http.ResponseWriter, r *http.Request) {
+	dataID, group, tenant := r.Form.Get("dataId"), r.Form.Get("group"), r.Form.Get("tenant")
+	if dataID == "" || group == "" {
+		writeError(w, http.StatusBadRequest, "Param 'dataId' and 'group' are required.")
+		return
+	}
+	key := configKey(dataID, group, tenant)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	switch r.Method {
+	case http.MethodGet:
+		content, ok := s.configs[key]
+		if !ok {
+			writeError(w, http.StatusNotFound, "config data not exist")
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain;charset=UTF-8")
+		w.Header().Set("Content-MD5", md5string(content))
+		_, _ = w.Write([]byte(content))
+	case http.MethodPost:
+		content := r.Form.Get("content")
+		if content == "" {
+			writeError(w, http.StatusBadRequest, "Param 'content' is required.")
+			return
+		}
+		//与Nacos 2.x一样, md5不一致时返回500, 配置不存在时直接新增; 1.x忽略casMd5
+		if cas := r.Header.Get("casMd5"); cas != "" && !strings.HasPrefix(s.version, "1.") {
+			if old, ok := s.configs[key]; ok && md5string(old) != cas {
+				w.WriteHeader(http.StatusInternalServerError)
+				writeJSON(w, map[string]interface{}{"code": 20002, "message": "Cas publish fail, server md5 may have changed.", "data": nil})
+				return
+			}
+		}
+		s.publishLocked(dataID, group, tenant, content)
+		s.wakeLocked()
+		_, _ = w.Write([]byte("true"))
+	case http.MethodDelete:
+		if old, ok := s.configs[key]; ok {
+			s.recordHistoryLocked(dataID, group, tenant, old, "D")
+			delete(s.configs, key)
+		}
+		s.wakeLocked()
+		_, _ = w.Write([]byte("true"))
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+//handleConfigHistory nid 存在时返回详情(不存在时与1.x一样返回空内容), 否则按nid倒序分页
+func (s *Server) handleConfigHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	dataID, group, tenant := r.Form.Get("dataId"), r.Form.Get("group"), r.Form.Get("tenant")
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if v := r.Form.Get("nid"); v != "" {
+		nid, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Param 'nid' is invalid.")
+			return
+		}
+		if nid >= 1 && nid <= int64(len(s.history)) {
+			if h := s.history[nid-1]; h.dataID == dataID && h.group == group && h.tenant == tenant {
+				writeJSON(w, h.json(true))
+			}
+		}
+		return
+	}
+	pageNo, err := strconv.Atoi(param(r, "pageNo", "1"))
+	if err != nil || pageNo < 1 {
+		writeError(w, http.StatusBadRequest, "Param 'pageNo' is invalid.")
+		return
+	}
+	pageSize, err := strconv.Atoi(param(r, "pageSize", "100"))
+	if err != nil || pageSize < 1 {
+		writeError(w, http.StatusBadRequest, "Param 'pageSize' is invalid.")
+		return
+	}
+	items := make([]map[string]interface{}, 0)
+	for i := len(s.history) - 1; i >= 0; i-- {
+		if h := s.history[i]; h.dataID == dataID && h.group == group && h.tenant == tenant {
+			items = append(items, h.json(false))
+		}
+	}
+	total := len(items)
+	start, end := (pageNo-1)*pageSize, pageNo*pageSize
+	if start > total {
+		start = total
+	}
+	if end > total {
+		end = total
+	}
+	writeJSON(w, map[string]interface{}{
+		"totalCount":     total,
+		"pageNumber":     pageNo,
+		"pagesAvailable": (total + pageSize - 1) / pageSize,
+		"pageItems":      items[start:end],
+	})
+}
+
+//wakeLocked 唤醒所有等待中的长轮询
+func (s *Server) wakeLocked() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+type listenContext struct {
+	dataID string
+	group  string
+	tenant string
+	md5    string
+}
+
+//changedLocked 返回md5与服务端不一致的配置
+func (s *Server) changedLocked(contexts []listenContext) string {
+	var sb strings.Builder
+	for _, v := range contexts {
+		var md5 string
+		if content, ok := s.configs[configKey(v.dataID, v.group, v.tenant)]; ok {
+			md5 = md5string(content)
+		}
+		if md5 == v.md5 {
+			continue
+		}
+		sb.WriteString(v.dataID + splitChar2 + v.group)
+		if v.tenant != "" {
+			sb.WriteString(splitChar2 + v.tenant)
+		}
+		sb.WriteString(splitChar1)
+	}
+	return sb.String()
+}
+
+func (s *Server) handleConfigListen(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	contexts := make([]listenContext, 0)
+	for _, item := range strings.Split(r.Form.Get("Listening-Configs"), splitChar1) {
+		t := strings.Split(item, splitChar2)
+		if len(t) < 3 {
+			continue
+		}
+		c := listenContext{dataID: t[0], group: t[1], md5: t[2]}
+		if len(t) > 3 {
+			c.tenant = t[3]
+		}
+		contexts = append(contexts, c)
+	}
+	if len(contexts) == 0 {
+		writeError(w, http.StatusBadRequest, "invalid probeModify")
+		return
+	}
+	//与Nacos一样提前500ms返回, 避免客户端超时
+	timeout, _ := strconv.ParseInt(r.Header.Get("Long-Pulling-Timeout"), 10, 64)
+	deadline := time.After(time.Duration(timeout)*time.Millisecond - 500*time.Millisecond)
+	for {
+		s.lock.Lock()
+		changed := s.changedLocked(contexts)
+		wake := s.changed
+		s.lock.Unlock()
+		if changed != "" || timeout == 0 {
+			_, _ = w.Write([]byte(url.QueryEscape(changed)))
+			return
+		}
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.done:
+			return
+		case <-deadline:
+			return
+		case <-wake:
+		}
+	}
+}
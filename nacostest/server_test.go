@@ -0,0 +1,367 @@
+package nacostest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	nacos "github.com/magicdvd/nacos-client"
+)
+
+func TestServerNaming(t *testing.T) {
+	srv := NewServer(BeatInterval(100*time.Millisecond), BeatTimeout(300*time.Millisecond, 600*time.Millisecond))
+	defer srv.Close()
+	a, err := nacos.NewServiceClient(srv.URL, nacos.DiscoveryIP("127.0.0.1"), nacos.LogLevel("error"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close(context.Background())
+	svcCh := make(chan *nacos.Service, 8)
+	if err = a.Subscribe("svc", func(s *nacos.Service) { svcCh <- s }); err != nil {
+		t.Fatal(err)
+	}
+	//等待udp推送, 直到实例数量符合预期
+	expectInstances := func(n int) {
+		timeout := time.After(3 * time.Second)
+		for {
+			select {
+			case s := <-svcCh:
+				if len(s.Instances) == n {
+					return
+				}
+			case <-timeout:
+				t.Fatalf("timeout waiting for %d instances", n)
+			}
+		}
+	}
+	if _, err = a.RegisterInstance("10.0.0.1", 80, "svc", nacos.ParamMetadata(map[string]interface{}{"k": "v"})); err != nil {
+		t.Fatal(err)
+	}
+	expectInstances(1)
+	instances := srv.Instances("public", "DEFAULT_GROUP@@svc")
+	if len(instances) != 1 || instances[0].IP != "10.0.0.1" || instances[0].Metadata["k"] != "v" {
+		t.Error("unexpected instances", instances)
+	}
+	//心跳使实例保持健康
+	time.Sleep(800 * time.Millisecond)
+	if instances = srv.Instances("public", "DEFAULT_GROUP@@svc"); len(instances) != 1 || !instances[0].Healthy {
+		t.Error("expected healthy instance", instances)
+	}
+	if err = a.DeregisterInstance("10.0.0.1", 80, "svc"); err != nil {
+		t.Fatal(err)
+	}
+	expectInstances(0)
+}
+
+func TestServerBeatExpire(t *testing.T) {
+	srv := NewServer(BeatTimeout(100*time.Millisecond, 200*time.Millisecond))
+	defer srv.Close()
+	a, err := nacos.NewServiceClient(srv.URL, nacos.DiscoveryIP("127.0.0.1"), nacos.LogLevel("error"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close(context.Background())
+	if _, err = a.RegisterInstance("10.0.0.1", 80, "svc"); err != nil {
+		t.Fatal(err)
+	}
+	//默认心跳间隔为5s, 实例会先不健康然后被删除
+	time.Sleep(500 * time.Millisecond)
+	if instances := srv.Instances("public", "DEFAULT_GROUP@@svc"); len(instances) != 0 {
+		t.Error("expected instance expired", instances)
+	}
+}
+
+func TestServerConfig(t *testing.T) {
+	srv := NewServer(Auth("nacos", "nacos"))
+	defer srv.Close()
+	if _, err := nacos.NewServiceClient(srv.URL, nacos.Auth("nacos", "wrong"), nacos.LogLevel("error")); !errors.Is(err, nacos.ErrUnauthorized) {
+		t.Error("expected login error")
+	}
+	a, err := nacos.NewServiceClient(srv.URL, nacos.Auth("nacos", "nacos"), nacos.ListenInterval(2*time.Second), nacos.LogLevel("error"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close(context.Background())
+	if _, err = a.GetConfig("a", "g"); !errors.Is(err, nacos.ErrConfigNotFound) {
+		t.Error("expected not found")
+	}
+	ch := make(chan string, 2)
+	errCh := a.ListenConfig("a", "g", func(s string) { ch <- s })
+	expect := func(v string) {
+		select {
+		case s := <-ch:
+			if s != v {
+				t.Errorf("expected %s, got %s", v, s)
+			}
+		case err := <-errCh:
+			t.Fatal(err)
+		case <-time.After(3 * time.Second):
+			t.Fatal("timeout waiting for", v)
+		}
+	}
+	if err = a.PublishConfig("a", "g", "v1"); err != nil {
+		t.Fatal(err)
+	}
+	expect("v1")
+	srv.SetConfig("a", "g", "", "v2")
+	expect("v2")
+	if content, err := a.GetConfig("a", "g"); err != nil || content != "v2" {
+		t.Error("unexpected config", content, err)
+	}
+	if err = a.RemoveConfig("a", "g"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := srv.Config("a", "g", ""); ok {
+		t.Error("expected config removed")
+	}
+}
+
+func TestServerPersistentInstance(t *testing.T) {
+	srv := NewServer(BeatTimeout(100*time.Millisecond, 200*time.Millisecond))
+	defer srv.Close()
+	a, err := nacos.NewServiceClient(srv.URL, nacos.DiscoveryIP("127.0.0.1"), nacos.LogLevel("error"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = a.RegisterInstance("", 80, "svc", nacos.ParamEphemeral(false), nacos.ParamHealthChecker(nacos.HealthChecker{Type: nacos.HealthCheckerTCP}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h, ok := srv.HealthChecker("public", "DEFAULT_GROUP@@svc", "DEFAULT"); !ok || h != `{"type":"TCP"}` {
+		t.Error("unexpected health checker", h)
+	}
+	//持久化实例没有心跳也不会过期, Close 时不注销
+	time.Sleep(300 * time.Millisecond)
+	a.Close(context.Background())
+	if instances := srv.Instances("public", "DEFAULT_GROUP@@svc"); len(instances) != 1 || instances[0].Ephemeral {
+		t.Fatal("expected persistent instance", instances)
+	}
+	b, err := nacos.NewServiceClient(srv.URL, nacos.DiscoveryIP("127.0.0.1"), nacos.LogLevel("error"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close(context.Background())
+	removed, err := b.ReconcilePersistentInstances("svc")
+	if err != nil || len(removed) != 1 {
+		t.Fatal("unexpected removed instances", removed, err)
+	}
+	if instances := srv.Instances("public", "DEFAULT_GROUP@@svc"); len(instances) != 0 {
+		t.Error("expected instance deregistered", instances)
+	}
+}
+
+func TestServerCatalog(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	a, err := nacos.NewServiceClient(srv.URL, nacos.DiscoveryIP("127.0.0.1"), nacos.LogLevel("error"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close(context.Background())
+	for _, name := range []string{"svc-c", "svc-a", "svc-b"} {
+		if _, err = a.RegisterInstance("10.0.0.1", 80, name, nacos.ParamClusterName("aa"), nacos.ParamWeight(2), nacos.ParamMetadata(map[string]interface{}{"k": "v"})); err != nil {
+			t.Fatal(err)
+		}
+	}
+	list, err := a.ListServices(1, 2)
+	if err != nil || list.Count != 3 || len(list.Services) != 2 || list.Services[0] != "svc-a" || list.Services[1] != "svc-b" {
+		t.Fatal("unexpected service list", list, err)
+	}
+	if list, _ = a.ListServices(2, 2); len(list.Services) != 1 || list.Services[0] != "svc-c" {
+		t.Error("unexpected second page", list)
+	}
+	if list, _ = a.ListServices(1, 10, nacos.ParamGroupName("other")); list.Count != 0 || len(list.Services) != 0 {
+		t.Error("expected empty group", list)
+	}
+	detail, err := a.GetServiceDetail("svc-a")
+	if err != nil || detail.Name != "svc-a" || detail.GroupName != "DEFAULT_GROUP" || len(detail.Clusters) != 1 || detail.Clusters[0].Name != "aa" || detail.Clusters[0].HealthChecker.Type != "TCP" {
+		t.Fatal("unexpected service detail", detail, err)
+	}
+	if _, err = a.GetServiceDetail("svc-x"); !errors.Is(err, nacos.ErrNotFound) {
+		t.Error("expected not found", err)
+	}
+	inst, err := a.GetInstanceDetail("10.0.0.1", 80, "svc-b", nacos.ParamClusterName("aa"))
+	if err != nil || inst.Ip != "10.0.0.1" || inst.Port != 80 || inst.Service != "DEFAULT_GROUP@@svc-b" || inst.Weight != 2 || inst.Metadata["k"] != "v" {
+		t.Fatal("unexpected instance detail", inst, err)
+	}
+	if _, err = a.GetInstanceDetail("10.0.0.1", 81, "svc-b", nacos.ParamClusterName("aa")); !errors.Is(err, nacos.ErrNotFound) {
+		t.Error("expected not found", err)
+	}
+}
+
+func TestServerServiceLifecycle(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	a, err := nacos.NewServiceClient(srv.URL, nacos.DiscoveryIP("127.0.0.1"), nacos.LogLevel("error"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close(context.Background())
+	def := nacos.ServiceDefinition{
+		ProtectThreshold: 0.5,
+		Metadata:         map[string]string{"owner": "ops"},
+		Selector:         &nacos.ServiceSelector{Type: "label", Expression: "CONSUMER.label.env = PROVIDER.label.env"},
+	}
+	if err = a.CreateService("svc", def, nacos.ParamGroupName("g")); err != nil {
+		t.Fatal(err)
+	}
+	if err = a.CreateService("svc", def, nacos.ParamGroupName("g")); !errors.Is(err, nacos.ErrBadRequest) {
+		t.Error("expected duplicate create to fail", err)
+	}
+	detail, err := a.GetServiceDetail("svc", nacos.ParamGroupName("g"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := detail.Definition(); d.ProtectThreshold != 0.5 || d.Metadata["owner"] != "ops" || *d.Selector != *def.Selector {
+		t.Error("unexpected definition", d)
+	}
+	if err = a.UpdateService("svc", nacos.ServiceDefinition{ProtectThreshold: 0.8}, nacos.ParamGroupName("g")); err != nil {
+		t.Fatal(err)
+	}
+	if detail, _ = a.GetServiceDetail("svc", nacos.ParamGroupName("g")); detail.ProtectThreshold != 0.8 || len(detail.Metadata) != 0 || detail.Selector.Type != "none" {
+		t.Error("expected definition to be replaced", detail)
+	}
+	if err = a.UpdateService("missing", def); !errors.Is(err, nacos.ErrBadRequest) {
+		t.Error("expected update of missing service to fail", err)
+	}
+	if _, err = a.RegisterInstance("10.0.0.1", 80, "svc", nacos.ParamGroupName("g")); err != nil {
+		t.Fatal(err)
+	}
+	if err = a.DeleteService("svc", nacos.ParamGroupName("g")); !errors.Is(err, nacos.ErrBadRequest) {
+		t.Error("expected delete with instances to fail", err)
+	}
+	if err = a.DeregisterInstance("10.0.0.1", 80, "svc", nacos.ParamGroupName("g")); err != nil {
+		t.Fatal(err)
+	}
+	if err = a.DeleteService("svc", nacos.ParamGroupName("g")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = a.GetServiceDetail("svc", nacos.ParamGroupName("g")); !errors.Is(err, nacos.ErrNotFound) {
+		t.Error("expected service deleted", err)
+	}
+}
+
+func TestServerNamespaces(t *testing.T) {
+	srv := NewServer(Auth("nacos", "nacos"))
+	defer srv.Close()
+	a, err := nacos.NewServiceClient(srv.URL, nacos.Auth("nacos", "nacos"), nacos.DiscoveryIP("127.0.0.1"), nacos.LogLevel("error"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close(context.Background())
+	if err = a.CreateNamespace("feature-1", "feature 1", "env for feature 1"); err != nil {
+		t.Fatal(err)
+	}
+	if err = a.CreateNamespace("feature-1", "feature 1", ""); !errors.Is(err, nacos.ErrBadRequest) {
+		t.Error("expected duplicate namespace to fail", err)
+	}
+	if err = a.PublishConfig("app.yaml", "g", "k: v", nacos.ParamConfigTenant("feature-1")); err != nil {
+		t.Fatal(err)
+	}
+	if err = a.UpdateNamespace("feature-1", "feature one", "renamed"); err != nil {
+		t.Fatal(err)
+	}
+	namespaces, err := a.ListNamespaces()
+	if err != nil || len(namespaces) != 2 {
+		t.Fatal("unexpected namespaces", namespaces, err)
+	}
+	if ns := namespaces[1]; ns.ID != "feature-1" || ns.Name != "feature one" || ns.Desc != "renamed" || ns.ConfigCount != 1 || ns.Quota != 200 {
+		t.Error("unexpected namespace", ns)
+	}
+	if err = a.DeleteNamespace("feature-1"); err != nil {
+		t.Fatal(err)
+	}
+	if namespaces, _ = a.ListNamespaces(); len(namespaces) != 1 || namespaces[0].Name != "public" {
+		t.Error("expected only public namespace", namespaces)
+	}
+	if err = a.DeleteNamespace("feature-1"); !errors.Is(err, nacos.ErrBadRequest) {
+		t.Error("expected deleting missing namespace to fail", err)
+	}
+}
+
+func TestServerConfigHistory(t *testing.T) {
+	srv := NewServer(Auth("nacos", "nacos"))
+	defer srv.Close()
+	a, err := nacos.NewServiceClient(srv.URL, nacos.Auth("nacos", "nacos"), nacos.LogLevel("error"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close(context.Background())
+	for _, v := range []string{"v1", "v2"} {
+		if err = a.PublishConfig("app.yaml", "g", v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	srv.SetConfig("app.yaml", "g", "", "v3")
+	page, err := a.ListConfigHistory("app.yaml", "g", 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.TotalCount != 3 || len(page.Items) != 3 || page.Items[0].OpType != "U" || page.Items[2].OpType != "I" || page.Items[0].CreatedTime.IsZero() {
+		t.Fatal("unexpected history page", page)
+	}
+	if h, err := a.GetConfigHistory("app.yaml", "g", page.Items[2].ID); err != nil || h.Content != "v1" {
+		t.Error("unexpected history", h, err)
+	}
+	if _, err = a.GetConfigHistory("app.yaml", "g", 100); !errors.Is(err, nacos.ErrNotFound) {
+		t.Error("expected missing history", err)
+	}
+	prev, err := a.GetPreviousConfig("app.yaml", "g")
+	if err != nil || prev.Content != "v2" {
+		t.Fatal("unexpected previous config", prev, err)
+	}
+	if err = a.RollbackConfig("app.yaml", "g", prev.ID); err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := srv.Config("app.yaml", "g", ""); s != "v2" {
+		t.Error("unexpected config after rollback", s)
+	}
+}
+
+func TestServerConfigCAS(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	a, err := nacos.NewServiceClient(srv.URL, nacos.LogLevel("error"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close(context.Background())
+	if err = a.PublishConfig("app.yaml", "g", "v1"); err != nil {
+		t.Fatal(err)
+	}
+	srv.SetConfig("app.yaml", "g", "", "v2")
+	if err = a.PublishConfigCAS("app.yaml", "g", "v3", nacos.ConfigMD5("v1")); !errors.Is(err, nacos.ErrConfigConflict) {
+		t.Fatal("expected conflict", err)
+	}
+	content, err := a.UpdateConfig("app.yaml", "g", func(current string) (string, error) {
+		return current + "-patched", nil
+	})
+	if err != nil || content != "v2-patched" {
+		t.Fatal("unexpected update", content, err)
+	}
+	if s, _ := srv.Config("app.yaml", "g", ""); s != "v2-patched" {
+		t.Error("unexpected config", s)
+	}
+}
+
+func TestServerConfigCASUnsupported(t *testing.T) {
+	srv := NewServer(ServerVersion("1.4.1"))
+	defer srv.Close()
+	a, err := nacos.NewServiceClient(srv.URL, nacos.LogLevel("error"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close(context.Background())
+	if err = a.PublishConfig("app.yaml", "g", "v1"); err != nil {
+		t.Fatal(err)
+	}
+	srv.SetConfig("app.yaml", "g", "", "v2")
+	//1.x忽略casMd5, 不能静默覆盖
+	if err = a.PublishConfigCAS("app.yaml", "g", "v3", nacos.ConfigMD5("v1")); !errors.Is(err, nacos.ErrNotSupported) {
+		t.Fatal("expected not supported", err)
+	}
+	if s, _ := srv.Config("app.yaml", "g", ""); s != "v2" {
+		t.Error("unexpected config", s)
+	}
+}
@@ -0,0 +1,81 @@
+package nacos
+
+import (
+	"context"
+	"fmt"
+)
+
+//Namespace 命名空间, 公共命名空间(public)的ID为空
+type Namespace struct {
+	ID   string `json:"namespace"`
+	Name string `json:"namespaceShowName"`
+	Desc string `json:"namespaceDesc"`
+	//Quota 配置数量的上限
+	Quota       int `json:"quota"`
+	ConfigCount int `json:"configCount"`
+	//Type 0为公共命名空间, 2为自定义命名空间
+	Type int `json:"type"`
+}
+
+func (c *ServiceClient) ListNamespaces(params ...Param) ([]*Namespace, error) {
+	return c.ListNamespacesWithContext(context.Background(), params...)
+}
+
+func (c *ServiceClient) ListNamespacesWithContext(ctx context.Context, params ...Param) ([]*Namespace, error) {
+	query := newParamMap()
+	query.Set(params...)
+	namespaces, err := c.namespaceManager().ListNamespaces(query.context(ctx))
+	if err != nil {
+		c.log.Error("ListNamespaces", "api", err)
+		return nil, err
+	}
+	return namespaces, nil
+}
+
+func (c *ServiceClient) CreateNamespace(namespaceID string, name string, desc string, params ...Param) error {
+	return c.CreateNamespaceWithContext(context.Background(), namespaceID, name, desc, params...)
+}
+
+//CreateNamespaceWithContext namespaceID 为空时由服务端生成, ID已存在时返回 ErrBadRequest
+func (c *ServiceClient) CreateNamespaceWithContext(ctx context.Context, namespaceID string, name string, desc string, params ...Param) error {
+	query := newParamMap()
+	query.Set(params...)
+	c.log.Debug(fmt.Sprintf("create namespace id: %s, name: %s", namespaceID, name))
+	if err := c.namespaceManager().CreateNamespace(query.context(ctx), &NamespaceRequest{ID: namespaceID, Name: name, Desc: desc}); err != nil {
+		c.log.Error("CreateNamespace", "api", err)
+		return err
+	}
+	return nil
+}
+
+func (c *ServiceClient) UpdateNamespace(namespaceID string, name string, desc string, params ...Param) error {
+	return c.UpdateNamespaceWithContext(context.Background(), namespaceID, name, desc, params...)
+}
+
+//UpdateNamespaceWithContext 修改命名空间的名称和描述
+func (c *ServiceClient) UpdateNamespaceWithContext(ctx context.Context, namespaceID string, name string, desc string, params ...Param) error {
+	query := newParamMap()
+	query.Set(params...)
+	c.log.Debug(fmt.Sprintf("update namespace id: %s, name: %s", namespaceID, name))
+	if err := c.namespaceManager().UpdateNamespace(query.context(ctx), &NamespaceRequest{ID: namespaceID, Name: name, Desc: desc}); err != nil {
+		c.log.Error("UpdateNamespace", "api", err)
+		return err
+	}
+	return nil
+}
+
+func (c *ServiceClient) DeleteNamespace(namespaceID string, params ...Param) error {
+	return c.DeleteNamespaceWithContext(context.Background(), namespaceID, params...)
+}
+
+//DeleteNamespaceWithContext 只删除命名空间本身, 其中的配置和服务不会被删除
+func (c *ServiceClient) DeleteNamespaceWithContext(ctx context.Context, namespaceID string, params ...Param) error {
+	query := newParamMap()
+	query.Set(params...)
+	c.log.Debug(fmt.Sprintf("delete namespace id: %s", namespaceID))
+	if err := c.namespaceManager().DeleteNamespace(query.context(ctx), namespaceID); err != nil {
+		c.log.Error("DeleteNamespace", "api", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package nacos
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+//namingCache 服务实例的本地快照, 目录结构 {dir}/{namespaceId}/{groupName@@serviceName[@@clusters]}
+type namingCache struct {
+	dir      string
+	maxStale time.Duration
+	log      LogInterface
+}
+
+type serviceSnapshot struct {
+	UpdateTime int64    `json:"updateTime"`
+	Service    *Service `json:"service"`
+}
+
+func (c *namingCache) enabled() bool {
+	return c != nil && c.dir != ""
+}
+
+//fresh 快照是否在允许的过期时间内, maxStale 为0表示不限制
+func (c *namingCache) fresh(svc *Service) bool {
+	return c.maxStale <= 0 || time.Since(svc.LastUpdateTime) <= c.maxStale
+}
+
+func (c *namingCache) save(nameSpaceID string, key string, svc *Service) {
+	if !c.enabled() {
+		return
+	}
+	b, err := json.Marshal(&serviceSnapshot{
+		UpdateTime: svc.LastUpdateTime.UnixNano() / int64(time.Millisecond),
+		Service:    svc,
+	})
+	if err != nil {
+		c.log.Error("saveServiceSnapshot", key, err)
+		return
+	}
+	if err = writeFileAtomic(filepath.Join(c.dir, url.PathEscape(nameSpaceID), url.PathEscape(key)), b); err != nil {
+		c.log.Error("saveServiceSnapshot", key, err)
+	}
+}
+
+//load 读取所有快照, 返回 namespaceId -> key -> Service
+func (c *namingCache) load() map[string]map[string]*Service {
+	m := make(map[string]map[string]*Service)
+	if !c.enabled() {
+		return m
+	}
+	nsDirs, err := ioutil.ReadDir(c.dir)
+	if err != nil {
+		return m
+	}
+	for _, nsDir := range nsDirs {
+		if !nsDir.IsDir() {
+			continue
+		}
+		nameSpaceID, err := url.PathUnescape(nsDir.Name())
+		if err != nil {
+			continue
+		}
+		files, err := ioutil.ReadDir(filepath.Join(c.dir, nsDir.Name()))
+		if err != nil {
+			continue
+		}
+		for _, f := range files {
+			//跳过目录和未完成写入的临时文件
+			if f.IsDir() || strings.HasPrefix(f.Name(), ".") {
+				continue
+			}
+			key, err := url.PathUnescape(f.Name())
+			if err != nil {
+				continue
+			}
+			b, err := ioutil.ReadFile(filepath.Join(c.dir, nsDir.Name(), f.Name()))
+			if err != nil {
+				continue
+			}
+			ss := new(serviceSnapshot)
+			if err = json.Unmarshal(b, ss); err != nil || ss.Service == nil {
+				c.log.Warn("loadServiceSnapshot", key, err)
+				continue
+			}
+			ss.Service.LastUpdateTime = time.Unix(0, ss.UpdateTime*int64(time.Millisecond))
+			if _, ok := m[nameSpaceID]; !ok {
+				m[nameSpaceID] = make(map[string]*Service)
+			}
+			m[nameSpaceID][key] = ss.Service
+		}
+	}
+	return m
+}
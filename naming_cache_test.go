@@ -0,0 +1,93 @@
+package nacos
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetServiceSnapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nacos-naming-cache")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	var down int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&down) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"name":"DEFAULT_GROUP@@my_test_service","clusters":"","cacheMillis":10000,"lastRefTime":1,"hosts":[{"ip":"10.0.0.1","port":8000,"weight":1,"healthy":true,"enabled":true}]}`))
+	}))
+	defer srv.Close()
+	a, err := NewServiceClient(srv.URL+"/nacos", DiscoveryIP("127.0.0.1"), LogLevel("error"), NamingCacheDir(dir))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err = a.GetService("my_test_service", false); err != nil {
+		t.Error(err)
+		return
+	}
+	atomic.StoreInt32(&down, 1)
+	//重启后从快照加载
+	b, err := NewServiceClient(srv.URL+"/nacos", DiscoveryIP("127.0.0.1"), LogLevel("error"), NamingCacheDir(dir))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	s, err := b.GetService("my_test_service", false)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(s.Instances) != 1 || s.Instances[0].Ip != "10.0.0.1" {
+		t.Error("unexpected snapshot service", s.Instances)
+	}
+	c, err := NewServiceClient(srv.URL+"/nacos", DiscoveryIP("127.0.0.1"), LogLevel("error"), NamingCacheDir(dir), NamingCacheMaxStale(time.Nanosecond))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err = c.GetService("my_test_service", false); err == nil {
+		t.Error("expected stale snapshot to be rejected")
+	}
+}
+
+func TestSubscribePollError(t *testing.T) {
+	var down, queries int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/nacos/v1/ns/instance/list" {
+			_, _ = w.Write([]byte("ok"))
+			return
+		}
+		if atomic.LoadInt32(&down) == 1 {
+			atomic.AddInt32(&queries, 1)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"name":"DEFAULT_GROUP@@my_test_service","clusters":"","cacheMillis":20,"lastRefTime":1,"hosts":[{"ip":"10.0.0.1","port":8000,"weight":1,"healthy":true,"enabled":true}]}`))
+	}))
+	defer srv.Close()
+	a, err := NewServiceClient(srv.URL+"/nacos", DiscoveryIP("127.0.0.1"), LogLevel("error"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close(context.Background())
+	if err = a.Subscribe("my_test_service", func(*Service) {}); err != nil {
+		t.Fatal(err)
+	}
+	//轮询失败时保留上次的结果并等待一个缓存周期后重试
+	atomic.StoreInt32(&down, 1)
+	time.Sleep(200 * time.Millisecond)
+	if n := atomic.LoadInt32(&queries); n == 0 || n > 20 {
+		t.Error("unexpected queries while server is down", n)
+	}
+}
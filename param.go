@@ -1,6 +1,7 @@
 package nacos
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/url"
@@ -27,6 +28,23 @@ const (
 	keyClientIP    string = "clientIP"
 	keyApp         string = "app"
 
+	keyPageNo   string = "pageNo"
+	keyPageSize string = "pageSize"
+	keySelector string = "selector"
+
+	keyProtectThreshold string = "protectThreshold"
+
+	//namespace use
+	keyCustomNamespaceID string = "customNamespaceId"
+	keyNamespace         string = "namespace"
+	keyNamespaceName     string = "namespaceName"
+	keyNamespaceShowName string = "namespaceShowName"
+	keyNamespaceDesc     string = "namespaceDesc"
+
+	keyHealthChecker         string = "healthChecker"
+	keyCheckPort             string = "checkPort"
+	keyUseInstancePort4Check string = "useInstancePort4Check"
+
 	//config use
 	keyAppName string = "appName"
 	keyTenant  string = "tenant"
@@ -36,10 +54,14 @@ const (
 	keyType    string = "type"
 	keyTag     string = "tag"
 
+	keySearch string = "search"
+	keyNid    string = "nid"
+
 	keyListenConfigs string = "Listening-Configs"
 )
 
-type beatInfo struct {
+//BeatInfo 临时实例的心跳内容
+type BeatInfo struct {
 	IP               string                 `json:"ip"`
 	Port             uint                   `json:"port"`
 	Weight           float64                `json:"weight"`
@@ -51,7 +73,7 @@ type beatInfo struct {
 	Interval         time.Duration          `json:"-"`
 }
 
-func (c *beatInfo) SplitServiceName() (string, string) {
+func (c *BeatInfo) SplitServiceName() (string, string) {
 	t := strings.Split(c.ServiceName, "@@")
 	return t[0], t[1]
 }
@@ -69,7 +91,7 @@ type paramMap struct {
 	serviceName   string
 	groupName     string
 	ephemeral     bool
-	beat          *beatInfo
+	beat          *BeatInfo
 	clusters      []string
 	udpPort       uint
 	clientIP      string
@@ -82,6 +104,18 @@ type paramMap struct {
 	tp            string
 	tag           string
 	listenConfigs string
+	filter        func(*Instance) bool
+	retryPolicy   *RetryPolicy
+	warmup        time.Duration
+	healthChecker *HealthChecker
+	pageNo        int
+	pageSize      int
+	selector      *ServiceSelector
+	threshold     float64
+	namespaceName string
+	namespaceDesc string
+	search        string
+	nid           int64
 }
 
 const (
@@ -105,21 +139,6 @@ func (c *paramMap) GetGrouppedServiceName() string {
 	return c.groupName + "@@" + c.serviceName
 }
 
-func (c *paramMap) ListenConfigs(content string) {
-	if content != "" {
-		content = md5string(content)
-	}
-	c.keys[keyListenConfigs] = true
-	delete(c.keys, keyDataID)
-	delete(c.keys, keyTenant)
-	delete(c.keys, keyGroup)
-	if c.tenant != "" {
-		c.listenConfigs = fmt.Sprintf("%s%s%s%s%s%s%s%s", c.dataID, splitChar2, c.group, splitChar2, content, splitChar2, c.tenant, splitChar1)
-		return
-	}
-	c.listenConfigs = fmt.Sprintf("%s%s%s%s%s%s", c.dataID, splitChar2, c.group, splitChar2, content, splitChar1)
-}
-
 func (c *paramMap) Parse() url.Values {
 	v := url.Values{}
 	for k := range c.keys {
@@ -188,8 +207,36 @@ func (c *paramMap) Parse() url.Values {
 			v.Set(k, fmt.Sprint(c.tp))
 		case keyTag:
 			v.Set(k, fmt.Sprint(c.tag))
+		case keySearch:
+			v.Set(k, c.search)
+		case keyNid:
+			v.Set(k, fmt.Sprint(c.nid))
 		case keyListenConfigs:
 			v.Set(k, c.listenConfigs)
+		case keyPageNo:
+			v.Set(k, fmt.Sprint(c.pageNo))
+		case keyPageSize:
+			v.Set(k, fmt.Sprint(c.pageSize))
+		case keyCustomNamespaceID, keyNamespace:
+			v.Set(k, c.nameSpaceID)
+		case keyNamespaceName, keyNamespaceShowName:
+			v.Set(k, c.namespaceName)
+		case keyNamespaceDesc:
+			v.Set(k, c.namespaceDesc)
+		case keyProtectThreshold:
+			v.Set(k, fmt.Sprint(c.threshold))
+		case keySelector:
+			b, _ := json.Marshal(c.selector)
+			v.Set(k, string(b))
+		case keyHealthChecker:
+			b, _ := json.Marshal(c.healthChecker)
+			v.Set(k, string(b))
+			v.Set(keyCheckPort, fmt.Sprint(c.healthChecker.CheckPort))
+			if c.healthChecker.CheckPort == 0 {
+				v.Set(keyUseInstancePort4Check, "true")
+			} else {
+				v.Set(keyUseInstancePort4Check, "false")
+			}
 		}
 	}
 	return v
@@ -300,7 +347,7 @@ func ParamEphemeral(b bool) Param {
 	})
 }
 
-func paramBeat(bi *beatInfo) Param {
+func paramBeat(bi *BeatInfo) Param {
 	return newParam(func(m *paramMap) {
 		m.keys[keyBeat] = true
 		m.beat = bi
@@ -376,3 +423,105 @@ func ParamConfigTag(s string) Param {
 		m.tag = s
 	})
 }
+
+func paramSearch(s string) Param {
+	return newParam(func(m *paramMap) {
+		m.keys[keySearch] = true
+		m.search = s
+	})
+}
+
+func paramNid(nid int64) Param {
+	return newParam(func(m *paramMap) {
+		m.keys[keyNid] = true
+		m.nid = nid
+	})
+}
+
+func paramListenConfigs(s string) Param {
+	return newParam(func(m *paramMap) {
+		m.keys[keyListenConfigs] = true
+		m.listenConfigs = s
+	})
+}
+
+//ParamInstanceFilter SelectInstances 使用的实例过滤条件, 不会发送到服务端
+func ParamInstanceFilter(f func(*Instance) bool) Param {
+	return newParam(func(m *paramMap) {
+		m.filter = f
+	})
+}
+
+//ParamWarmup 注册时从较小的权重开始, 在 d 时间内逐步增加到 ParamWeight 指定的权重, 不会发送到服务端
+func ParamWarmup(d time.Duration) Param {
+	return newParam(func(m *paramMap) {
+		m.warmup = d
+	})
+}
+
+func paramPage(pageNo, pageSize int) Param {
+	return newParam(func(m *paramMap) {
+		m.keys[keyPageNo] = true
+		m.keys[keyPageSize] = true
+		m.pageNo = pageNo
+		m.pageSize = pageSize
+	})
+}
+
+//paramNamespace 创建命名空间时使用 customNamespaceId/namespaceName, 修改时使用 namespace/namespaceShowName
+func paramNamespace(req *NamespaceRequest, create bool) Param {
+	return newParam(func(m *paramMap) {
+		if create {
+			m.keys[keyCustomNamespaceID] = true
+			m.keys[keyNamespaceName] = true
+		} else {
+			m.keys[keyNamespace] = true
+			m.keys[keyNamespaceShowName] = true
+		}
+		m.keys[keyNamespaceDesc] = true
+		m.nameSpaceID = req.ID
+		m.namespaceName = req.Name
+		m.namespaceDesc = req.Desc
+	})
+}
+
+func paramProtectThreshold(f float64) Param {
+	return newParam(func(m *paramMap) {
+		m.keys[keyProtectThreshold] = true
+		m.threshold = f
+	})
+}
+
+//ParamServiceSelector ListServices 按选择器过滤服务
+func ParamServiceSelector(s ServiceSelector) Param {
+	return newParam(func(m *paramMap) {
+		m.keys[keySelector] = true
+		m.selector = &s
+	})
+}
+
+//ParamHealthChecker 注册持久化实例时设置所在集群的健康检查方式, 临时实例由心跳保活, 会忽略
+func ParamHealthChecker(h HealthChecker) Param {
+	return newParam(func(m *paramMap) {
+		m.healthChecker = &h
+	})
+}
+
+func paramHealthChecker(h *HealthChecker) Param {
+	return newParam(func(m *paramMap) {
+		m.keys[keyHealthChecker] = true
+		m.healthChecker = h
+	})
+}
+
+//ParamRetryPolicy 覆盖单次调用的重试策略, 不会发送到服务端
+func ParamRetryPolicy(p RetryPolicy) Param {
+	return newParam(func(m *paramMap) {
+		m.retryPolicy = &p
+	})
+}
+
+//context 带上单次调用的重试策略
+func (c *paramMap) context(ctx context.Context) context.Context {
+	return withRetryPolicy(ctx, c.retryPolicy)
+}
@@ -0,0 +1,118 @@
+package nacos
+
+import (
+	"context"
+
+	"github.com/magicdvd/nacos-client/constant"
+)
+
+const (
+	HealthCheckerTCP  = "TCP"
+	HealthCheckerHTTP = "HTTP"
+	//HealthCheckerNone 不检查, 实例始终为健康
+	HealthCheckerNone = "NONE"
+
+	//MetadataOwner 本客户端注册的持久化实例的metadata中保存 AppName, ReconcilePersistentInstances 只注销 AppName 相同的实例
+	MetadataOwner = "nacos-client.owner"
+)
+
+//HealthChecker 持久化实例不发送心跳, 由服务端按集群配置的方式主动检查健康状态
+type HealthChecker struct {
+	//Type TCP/HTTP/NONE
+	Type string `json:"type"`
+	//Path HTTP检查的路径
+	Path string `json:"path,omitempty"`
+	//Headers HTTP检查的请求头, 格式为 k1:v1|k2:v2
+	Headers string `json:"headers,omitempty"`
+	//ExpectedResponseCode HTTP检查期望的状态码
+	ExpectedResponseCode int `json:"expectedResponseCode,omitempty"`
+	//CheckPort 检查的端口, 0表示使用实例的端口
+	CheckPort uint `json:"-"`
+}
+
+func (c *ServiceClient) ListPersistentInstances(serviceName string, params ...Param) ([]*Instance, error) {
+	return c.ListPersistentInstancesWithContext(context.Background(), serviceName, params...)
+}
+
+//ListPersistentInstancesWithContext 服务中IP为 DiscoveryIP 的持久化实例, 包括不健康和已下线的实例
+//持久化实例在客户端退出后仍然保留, 启动时可以用来找到之前注册的实例
+func (c *ServiceClient) ListPersistentInstancesWithContext(ctx context.Context, serviceName string, params ...Param) ([]*Instance, error) {
+	query := newParamMap()
+	query.Set(
+		paramServiceName(serviceName),
+		ParamGroupName(constant.DefaultGroupName),
+		ParamNameSpaceID(c.opts.defautNameSpaceID),
+	)
+	query.Set(params...)
+	query.Set(ParamHealthy(false))
+	ctx = query.context(ctx)
+	//不使用缓存, 也不写入缓存, 避免 GetService 拿到不健康的实例
+	svc, err := c.transport.QueryInstances(ctx, newServiceQuery(query))
+	if err != nil {
+		c.log.Error("ListPersistentInstances", "api", err)
+		return nil, err
+	}
+	instances := make([]*Instance, 0)
+	for _, v := range svc.Instances {
+		if v.Ephemeral || v.Ip != c.opts.discoveryIP {
+			continue
+		}
+		if query.filter != nil && !query.filter(v) {
+			continue
+		}
+		instances = append(instances, v)
+	}
+	return instances, nil
+}
+
+func (c *ServiceClient) ReconcilePersistentInstances(serviceName string, params ...Param) ([]*Instance, error) {
+	return c.ReconcilePersistentInstancesWithContext(context.Background(), serviceName, params...)
+}
+
+//ReconcilePersistentInstancesWithContext 注销 ListPersistentInstances 中 AppName 相同但没有被本客户端注册的实例, 返回已注销的实例
+//在启动时注册完需要的持久化实例后调用, 清理之前运行时注册但不再使用的实例; 出错时继续处理其余实例并返回第一个错误
+//同一IP上的其他进程需要使用不同的 AppName, 否则会注销对方的实例; 没有 MetadataOwner 的实例不会注销
+func (c *ServiceClient) ReconcilePersistentInstancesWithContext(ctx context.Context, serviceName string, params ...Param) ([]*Instance, error) {
+	instances, err := c.ListPersistentInstancesWithContext(ctx, serviceName, params...)
+	if err != nil {
+		return nil, err
+	}
+	query := newParamMap()
+	query.Set(
+		paramServiceName(serviceName),
+		ParamGroupName(constant.DefaultGroupName),
+		ParamNameSpaceID(c.opts.defautNameSpaceID),
+	)
+	query.Set(params...)
+	ctx = query.context(ctx)
+	removed := make([]*Instance, 0)
+	for _, v := range instances {
+		//其他进程或其他客户端注册的实例
+		if v.Metadata[MetadataOwner] != c.opts.appName {
+			continue
+		}
+		q := query.clone()
+		q.Set(paramIPAddress(v.Ip), paramPort(uint(v.Port)), ParamClusterName(v.ClusterName), ParamEphemeral(false))
+		if _, ok := c.registration(q); ok {
+			continue
+		}
+		if e := c.deregister(ctx, q); e != nil {
+			if err == nil {
+				err = e
+			}
+			continue
+		}
+		removed = append(removed, v)
+	}
+	return removed, err
+}
+
+//withOwner 返回写入 MetadataOwner 的metadata副本, 不修改传入的map
+func withOwner(metadata map[string]interface{}, owner string) map[string]interface{} {
+	m := make(map[string]interface{}, len(metadata)+1)
+	for k, v := range metadata {
+		m[k] = v
+	}
+	m[MetadataOwner] = owner
+	return m
+}
@@ -0,0 +1,165 @@
+package nacos
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestPersistentInstance(t *testing.T) {
+	fake := NewFakeTransport()
+	a, err := NewServiceClient("", WithTransport(fake), DiscoveryIP("127.0.0.1"), LogLevel("error"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checker := HealthChecker{Type: HealthCheckerHTTP, Path: "/health", ExpectedResponseCode: 200}
+	r, err := a.RegisterInstance("", 80, "svc", ParamEphemeral(false), ParamHealthChecker(checker))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Heartbeat() != nil {
+		t.Error("expected no heartbeat for persistent instance")
+	}
+	if h, ok := fake.HealthChecker("public", "DEFAULT_GROUP@@svc", "DEFAULT"); !ok || h != checker {
+		t.Error("unexpected health checker", h, ok)
+	}
+	//之前运行时注册的实例, 以及其他机器上的实例
+	for _, v := range []*InstanceRequest{{IP: "127.0.0.1", Port: 81, Metadata: map[string]interface{}{MetadataOwner: "app-127.0.0.1"}}, {IP: "10.0.0.9", Port: 80}} {
+		v.NameSpaceID, v.GroupName, v.ServiceName, v.ClusterName = "public", "DEFAULT_GROUP", "svc", "DEFAULT"
+		if err = fake.RegisterInstance(context.Background(), v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if instances, err := a.ListPersistentInstances("svc"); err != nil || len(instances) != 2 {
+		t.Fatal("unexpected persistent instances", instances, err)
+	}
+	removed, err := a.ReconcilePersistentInstances("svc")
+	if err != nil || len(removed) != 1 || removed[0].Port != 81 {
+		t.Fatal("unexpected removed instances", removed, err)
+	}
+	if instances, _ := a.ListPersistentInstances("svc"); len(instances) != 1 || instances[0].Port != 80 {
+		t.Error("unexpected persistent instances", instances)
+	}
+	//持久化实例在 Close 时不注销
+	a.Close(context.Background())
+	if svc, _ := fake.QueryInstances(context.Background(), &ServiceQuery{NameSpaceID: "public", GroupName: "DEFAULT_GROUP", ServiceName: "svc"}); len(svc.Instances) != 2 {
+		t.Error("expected persistent instance to survive close", svc.Instances)
+	}
+	b, err := NewServiceClient("", WithTransport(fake), DiscoveryIP("127.0.0.1"), LogLevel("error"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close(context.Background())
+	//未注册的持久化实例需要指定 ParamEphemeral(false)
+	if err = b.DeregisterInstance("", 80, "svc"); err != nil {
+		t.Fatal(err)
+	}
+	if instances, _ := b.ListPersistentInstances("svc"); len(instances) != 1 {
+		t.Error("expected instance to remain", instances)
+	}
+	if err = b.DeregisterInstance("", 80, "svc", ParamEphemeral(false)); err != nil {
+		t.Fatal(err)
+	}
+	if instances, _ := b.ListPersistentInstances("svc"); len(instances) != 0 {
+		t.Error("expected instance deregistered", instances)
+	}
+}
+
+func TestReconcileOwnedInstances(t *testing.T) {
+	fake := NewFakeTransport()
+	other, err := NewServiceClient("", WithTransport(fake), DiscoveryIP("127.0.0.1"), AppName("other"), LogLevel("error"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close(context.Background())
+	//同一IP上其他进程注册的实例, 以及没有所有者的实例
+	if _, err = other.RegisterInstance("", 81, "svc", ParamEphemeral(false)); err != nil {
+		t.Fatal(err)
+	}
+	if err = fake.RegisterInstance(context.Background(), &InstanceRequest{NameSpaceID: "public", GroupName: "DEFAULT_GROUP", ServiceName: "svc", ClusterName: "DEFAULT", IP: "127.0.0.1", Port: 82}); err != nil {
+		t.Fatal(err)
+	}
+	a, err := NewServiceClient("", WithTransport(fake), DiscoveryIP("127.0.0.1"), AppName("self"), LogLevel("error"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close(context.Background())
+	r, err := a.RegisterInstance("", 80, "svc", ParamEphemeral(false), ParamMetadata(map[string]interface{}{"k": "v"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	//替换metadata后仍然保留所有者
+	if err = r.UpdateMetadata(map[string]interface{}{"k": "v2"}); err != nil {
+		t.Fatal(err)
+	}
+	instances, err := a.ListPersistentInstances("svc")
+	if err != nil || len(instances) != 3 {
+		t.Fatal("unexpected persistent instances", instances, err)
+	}
+	for _, v := range instances {
+		if v.Port == 80 && (v.Metadata[MetadataOwner] != "self" || v.Metadata["k"] != "v2") {
+			t.Error("unexpected metadata", v.Metadata)
+		}
+	}
+	removed, err := a.ReconcilePersistentInstances("svc")
+	if err != nil || len(removed) != 0 {
+		t.Fatal("unexpected removed instances", removed, err)
+	}
+	if instances, _ = a.ListPersistentInstances("svc"); len(instances) != 3 {
+		t.Error("expected instances of other owners to survive", instances)
+	}
+}
+
+func TestDeregisterPersistentInstance(t *testing.T) {
+	fake := NewFakeTransport()
+	a, err := NewServiceClient("", WithTransport(fake), DiscoveryIP("127.0.0.1"), LogLevel("error"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close(context.Background())
+	r, err := a.RegisterInstance("", 80, "svc", ParamEphemeral(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake.SetError(errors.New("unavailable"))
+	if err = a.DeregisterInstance("", 80, "svc"); err == nil {
+		t.Fatal("expected deregister error")
+	}
+	fake.SetError(nil)
+	//注销失败后仍然保留, 可以重试
+	if dup, _ := a.RegisterInstance("", 80, "svc", ParamEphemeral(false)); dup != r {
+		t.Error("expected registration to be kept after failure")
+	}
+	if err = r.Deregister(); err != nil {
+		t.Fatal(err)
+	}
+	if instances, _ := a.ListPersistentInstances("svc"); len(instances) != 0 {
+		t.Error("expected instance deregistered", instances)
+	}
+}
+
+//clusterErrorTransport 修改集群健康检查配置失败
+type clusterErrorTransport struct {
+	*FakeTransport
+}
+
+func (t *clusterErrorTransport) UpdateCluster(ctx context.Context, req *ClusterRequest) error {
+	return &StatusError{Code: http.StatusInternalServerError, Body: "update cluster failed"}
+}
+
+func TestRegisterPersistentInstanceClusterError(t *testing.T) {
+	fake := &clusterErrorTransport{FakeTransport: NewFakeTransport()}
+	a, err := NewServiceClient("", WithTransport(fake), DiscoveryIP("127.0.0.1"), LogLevel("error"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close(context.Background())
+	checker := HealthChecker{Type: HealthCheckerTCP}
+	if _, err = a.RegisterInstance("", 80, "svc", ParamEphemeral(false), ParamHealthChecker(checker)); !errors.Is(err, ErrServerError) {
+		t.Fatal("expected update cluster error", err)
+	}
+	if instances, err := a.ListPersistentInstances("svc"); err != nil || len(instances) != 0 {
+		t.Error("expected instance deregistered after update cluster failed", instances, err)
+	}
+}
@@ -0,0 +1,197 @@
+package nacos
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/magicdvd/nacos-client/constant"
+)
+
+//ErrRegistrationClosed 实例已经注销
+var ErrRegistrationClosed = errors.New("nacos: registration is deregistered")
+
+//Registration RegisterInstance 返回的已注册实例, 记住注册时的参数, 注销和修改时不需要重复传入
+//修改通过 UpdateInstance 原地更新服务端的实例, 成功后同步到心跳中
+type Registration struct {
+	client    *ServiceClient
+	heartbeat *Heartbeat
+	//updateLock 保证修改按调用顺序生效, 请求期间不持有 lock
+	updateLock sync.Mutex
+	lock       sync.Mutex
+	query      *paramMap
+	closed     bool
+}
+
+//clone 复制注册参数, metadata 单独复制避免修改影响已注册的参数
+func (c *paramMap) clone() *paramMap {
+	cp := *c
+	cp.keys = make(map[string]bool, len(c.keys))
+	for k, v := range c.keys {
+		cp.keys[k] = v
+	}
+	cp.metadata = make(map[string]interface{}, len(c.metadata))
+	for k, v := range c.metadata {
+		cp.metadata[k] = v
+	}
+	return &cp
+}
+
+//params 返回注册参数的副本
+func (r *Registration) params() *paramMap {
+	return r.current().clone()
+}
+
+//current 当前的注册参数, 修改时整体替换, 返回值不会再被修改
+func (r *Registration) current() *paramMap {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.query
+}
+
+func (r *Registration) IP() string {
+	return r.current().ipAddress
+}
+
+func (r *Registration) Port() uint {
+	return r.current().port
+}
+
+//ServiceName group@@serviceName
+func (r *Registration) ServiceName() string {
+	return r.current().GetGrouppedServiceName()
+}
+
+func (r *Registration) ClusterName() string {
+	return r.current().clusterName
+}
+
+func (r *Registration) NameSpaceID() string {
+	return r.current().nameSpaceID
+}
+
+//Heartbeat 临时实例的心跳, 持久化实例返回nil
+func (r *Registration) Heartbeat() *Heartbeat {
+	return r.heartbeat
+}
+
+//Status 心跳状态, 持久化实例只有注销后 Stopped 为true
+func (r *Registration) Status() HeartbeatStatus {
+	if r.heartbeat != nil {
+		return r.heartbeat.Status()
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return HeartbeatStatus{Healthy: !r.closed, Stopped: r.closed}
+}
+
+func (r *Registration) Deregister() error {
+	return r.DeregisterWithContext(context.Background())
+}
+
+func (r *Registration) DeregisterWithContext(ctx context.Context) error {
+	r.lock.Lock()
+	if r.closed {
+		r.lock.Unlock()
+		return nil
+	}
+	r.closed = true
+	query := r.query
+	r.lock.Unlock()
+	err := r.client.deregister(ctx, query)
+	if err != nil && !query.ephemeral {
+		//持久化实例注销失败时仍然保留, 可以重试
+		r.lock.Lock()
+		r.closed = false
+		r.lock.Unlock()
+	}
+	return err
+}
+
+func (r *Registration) UpdateWeight(weight float64) error {
+	return r.UpdateWeightWithContext(context.Background(), weight)
+}
+
+func (r *Registration) UpdateWeightWithContext(ctx context.Context, weight float64) error {
+	return r.update(ctx, ParamWeight(weight))
+}
+
+//UpdateMetadata 替换实例的metadata
+func (r *Registration) UpdateMetadata(metadata map[string]interface{}) error {
+	return r.UpdateMetadataWithContext(context.Background(), metadata)
+}
+
+func (r *Registration) UpdateMetadataWithContext(ctx context.Context, metadata map[string]interface{}) error {
+	return r.update(ctx, ParamMetadata(metadata))
+}
+
+//SetEnabled 上线/下线实例, 下线的实例不会被 SelectInstances 选中
+func (r *Registration) SetEnabled(enabled bool) error {
+	return r.SetEnabledWithContext(context.Background(), enabled)
+}
+
+func (r *Registration) SetEnabledWithContext(ctx context.Context, enabled bool) error {
+	return r.update(ctx, ParamEnabled(enabled))
+}
+
+func (r *Registration) WarmUp(target float64, duration time.Duration) error {
+	return r.WarmUpWithContext(context.Background(), target, duration)
+}
+
+//WarmUpWithContext 在 duration 时间内分 constant.WarmupSteps 次将权重逐步增加到 target, 阻塞直到完成
+//ctx 结束或实例注销时停止, 期间调用 UpdateWeight 会被后续的步骤覆盖
+func (r *Registration) WarmUpWithContext(ctx context.Context, target float64, duration time.Duration) error {
+	return r.warmUp(ctx, target, duration, 1)
+}
+
+//warmUp 从第 step 步开始增加权重
+func (r *Registration) warmUp(ctx context.Context, target float64, duration time.Duration, step int) error {
+	interval := duration / constant.WarmupSteps
+	for ; step <= constant.WarmupSteps; step++ {
+		if err := r.update(ctx, ParamWeight(target*float64(step)/constant.WarmupSteps)); err != nil {
+			return err
+		}
+		if step == constant.WarmupSteps {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+	return nil
+}
+
+//update 修改实例(PUT /v1/ns/instance), 成功后更新保存的参数和心跳内容
+func (r *Registration) update(ctx context.Context, params ...Param) error {
+	r.updateLock.Lock()
+	defer r.updateLock.Unlock()
+	r.lock.Lock()
+	if r.closed {
+		r.lock.Unlock()
+		return ErrRegistrationClosed
+	}
+	query := r.query.clone()
+	r.lock.Unlock()
+	query.Set(params...)
+	//替换metadata时保留所有者
+	if !query.ephemeral {
+		query.metadata = withOwner(query.metadata, r.client.opts.appName)
+	}
+	ctx = query.context(ctx)
+	if err := r.client.updateInstance(ctx, query); err != nil {
+		return err
+	}
+	r.lock.Lock()
+	r.query = query
+	r.lock.Unlock()
+	if r.heartbeat != nil {
+		r.heartbeat.update(func(b *BeatInfo) {
+			b.Weight = query.weight
+			b.Metadata = query.metadata
+		})
+	}
+	return nil
+}
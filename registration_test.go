@@ -0,0 +1,103 @@
+package nacos
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRegistration(t *testing.T) {
+	fake := NewFakeTransport()
+	a, err := NewServiceClient("", WithTransport(fake), DiscoveryIP("127.0.0.1"), LogLevel("error"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close(context.Background())
+	r, err := a.RegisterInstance("10.0.0.1", 80, "svc", ParamClusterName("aa"), ParamGroupName("g"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.ServiceName() != "g@@svc" || r.ClusterName() != "aa" || !r.Status().Healthy {
+		t.Error("unexpected registration", r.ServiceName(), r.ClusterName(), r.Status())
+	}
+	if dup, _ := a.RegisterInstance("10.0.0.1", 80, "svc", ParamClusterName("aa"), ParamGroupName("g")); dup != r {
+		t.Error("expected duplicate register to return the same registration")
+	}
+	instance := func() *Instance {
+		svc, err := a.GetService("svc", false, ParamGroupName("g"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(svc.Instances) != 1 {
+			return nil
+		}
+		return svc.Instances[0]
+	}
+	if err = r.UpdateWeight(5); err != nil {
+		t.Fatal(err)
+	}
+	if err = r.UpdateMetadata(map[string]interface{}{"version": "v2"}); err != nil {
+		t.Fatal(err)
+	}
+	if err = r.SetEnabled(false); err != nil {
+		t.Fatal(err)
+	}
+	if i := instance(); i == nil || i.Weight != 5 || i.Metadata["version"] != "v2" || i.Enable || i.ClusterName != "aa" {
+		t.Error("unexpected instance", i)
+	}
+	if b := r.Heartbeat().snapshot(); b.Weight != 5 || b.Metadata["version"] != "v2" {
+		t.Error("expected heartbeat to be updated", b)
+	}
+	if err = r.Deregister(); err != nil {
+		t.Fatal(err)
+	}
+	if i := instance(); i != nil {
+		t.Error("expected instance deregistered", i)
+	}
+	if err = r.UpdateWeight(1); err != ErrRegistrationClosed {
+		t.Error("expected registration closed", err)
+	}
+	if !r.Status().Stopped {
+		t.Error("expected stopped status")
+	}
+}
+
+func TestUpdateInstance(t *testing.T) {
+	fake := NewFakeTransport()
+	a, err := NewServiceClient("", WithTransport(fake), DiscoveryIP("127.0.0.1"), LogLevel("error"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close(context.Background())
+	if err = a.UpdateInstance("10.0.0.2", 80, "svc", ParamWeight(2)); !errors.Is(err, ErrBadRequest) {
+		t.Error("expected bad request for unknown instance", err)
+	}
+	r, err := a.RegisterInstance("10.0.0.1", 80, "svc", ParamWeight(4), ParamWarmup(50*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	weight := func() float64 {
+		svc, err := a.GetService("svc", false)
+		if err != nil || len(svc.Instances) != 1 {
+			t.Fatal("unexpected service", svc, err)
+		}
+		return svc.Instances[0].Weight
+	}
+	if w := weight(); w >= 4 {
+		t.Error("expected warmup to start with a small weight", w)
+	}
+	deadline := time.Now().Add(time.Second)
+	for weight() != 4 {
+		if time.Now().After(deadline) {
+			t.Fatal("timeout waiting for warmup", weight())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err = a.UpdateInstance("10.0.0.1", 80, "svc", ParamWeight(7)); err != nil {
+		t.Fatal(err)
+	}
+	if w := weight(); w != 7 || r.Heartbeat().snapshot().Weight != 7 {
+		t.Error("expected registration to be updated", w)
+	}
+}
@@ -0,0 +1,107 @@
+package nacos
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/magicdvd/nacos-client/constant"
+)
+
+//RetryPolicy http请求失败后的重试策略, 每次重试会依次尝试所有可用节点
+//第n次重试前等待 min(BaseDelay*2^(n-1), MaxDelay), 并随机浮动 ±Jitter
+//默认只重试幂等的GET/PUT/DELETE请求, 配置监听的长轮询不重试
+type RetryPolicy struct {
+	//MaxAttempts 最多请求次数(包含第一次), 小于等于1时不重试
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	//Jitter 随机浮动的比例, 取值0~1
+	Jitter float64
+	//Retryable 判断错误是否可以重试, 为nil时使用 DefaultRetryable
+	Retryable func(err error) bool
+	//RetryNonIdempotent 是否重试POST(注册实例/发布配置等), 请求可能已经在服务端生效
+	RetryNonIdempotent bool
+}
+
+//DefaultRetryPolicy 默认的重试策略, 最多3次, 100ms起指数退避, 最大3s
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: constant.DefaultRetryMaxAttempts,
+		BaseDelay:   constant.DefaultRetryBaseDelay,
+		MaxDelay:    constant.DefaultRetryMaxDelay,
+		Jitter:      constant.DefaultRetryJitter,
+	}
+}
+
+//NoRetry 不重试
+func NoRetry() RetryPolicy {
+	return RetryPolicy{MaxAttempts: 1}
+}
+
+//DefaultRetryable 网络错误, 429和5xx可以重试; 客户端取消/超时和其他状态码不重试
+func DefaultRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, errNoServerAddr) {
+		return false
+	}
+	var e *StatusError
+	if errors.As(err, &e) {
+		return e.Code == http.StatusTooManyRequests || e.Code >= http.StatusInternalServerError
+	}
+	return true
+}
+
+func (p *RetryPolicy) retryable(method string, err error) bool {
+	if !p.RetryNonIdempotent && !idempotent(method) {
+		return false
+	}
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	return DefaultRetryable(err)
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+//backoff 第attempt次请求失败后的等待时间
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < attempt && (p.MaxDelay <= 0 || d < p.MaxDelay); i++ {
+		d *= 2
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if p.Jitter > 0 && d > 0 {
+		d += time.Duration((rand.Float64()*2 - 1) * p.Jitter * float64(d))
+	}
+	if d < 0 {
+		d = 0
+	}
+	return d
+}
+
+type retryPolicyKey struct{}
+
+//withRetryPolicy 单次调用的重试策略通过context传给httpClient
+func withRetryPolicy(ctx context.Context, p *RetryPolicy) context.Context {
+	if p == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, retryPolicyKey{}, p)
+}
+
+func retryPolicyFromContext(ctx context.Context, def *RetryPolicy) *RetryPolicy {
+	if p, ok := ctx.Value(retryPolicyKey{}).(*RetryPolicy); ok {
+		return p
+	}
+	return def
+}
@@ -0,0 +1,71 @@
+package nacos
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		//每3次请求成功1次
+		if atomic.AddInt32(&hits, 1)%3 != 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("true"))
+	}))
+	defer srv.Close()
+	a, err := NewServiceClient(srv.URL+"/nacos", WithRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, RetryNonIdempotent: true}), LogLevel("error"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = a.PublishConfig("a", "g", "v"); err != nil {
+		t.Error(err)
+	}
+	if n := atomic.LoadInt32(&hits); n != 3 {
+		t.Errorf("expected 3 attempts, got %d", n)
+	}
+	err = a.PublishConfig("a", "g", "v", ParamRetryPolicy(NoRetry()))
+	if !errors.Is(err, ErrServerError) {
+		t.Error("expected server error", err)
+	}
+	if n := atomic.LoadInt32(&hits); n != 4 {
+		t.Errorf("expected 1 attempt, got %d", n-3)
+	}
+	//Retryable 返回false时不重试
+	err = a.PublishConfig("a", "g", "v", ParamRetryPolicy(RetryPolicy{MaxAttempts: 5, Retryable: func(err error) bool { return false }, RetryNonIdempotent: true}))
+	if err == nil || atomic.LoadInt32(&hits) != 5 {
+		t.Error("expected no retry", err)
+	}
+	//默认不重试POST
+	atomic.StoreInt32(&hits, 0)
+	err = a.PublishConfig("a", "g", "v", ParamRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}))
+	if !errors.Is(err, ErrServerError) || atomic.LoadInt32(&hits) != 1 {
+		t.Error("expected POST not retried", err, atomic.LoadInt32(&hits))
+	}
+	//DELETE 是幂等的, 按策略重试
+	atomic.StoreInt32(&hits, 1)
+	if err = a.RemoveConfig("a", "g", ParamRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond})); err != nil || atomic.LoadInt32(&hits) != 3 {
+		t.Error("expected DELETE retried", err, atomic.LoadInt32(&hits))
+	}
+}
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	p := &RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	for attempt, want := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 4: 800 * time.Millisecond, 10: time.Second} {
+		if got := p.backoff(attempt); got != want {
+			t.Errorf("backoff(%d) = %s, want %s", attempt, got, want)
+		}
+	}
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if d := p.backoff(1); d < 50*time.Millisecond || d > 150*time.Millisecond {
+			t.Fatal("jitter out of range", d)
+		}
+	}
+}
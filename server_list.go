@@ -0,0 +1,196 @@
+package nacos
+
+import (
+	"errors"
+	"math/rand"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/magicdvd/nacos-client/constant"
+)
+
+var errNoServerAddr = errors.New("no nacos server address")
+
+type server struct {
+	addr        string
+	contextPath string
+	failUntil   time.Time
+}
+
+func (c *server) url(apiURI string) string {
+	return c.addr + c.contextPath + apiURI
+}
+
+//grpcAddr gRPC地址, 端口为http端口加上 offset
+func (c *server) grpcAddr(offset int) (string, error) {
+	u, err := url.Parse(c.addr)
+	if err != nil {
+		return "", err
+	}
+	port := u.Port()
+	if port == "" {
+		port = constant.DefaultServerPort
+	}
+	p, err := strconv.Atoi(port)
+	if err != nil {
+		return "", err
+	}
+	return net.JoinHostPort(u.Hostname(), strconv.Itoa(p+offset)), nil
+}
+
+type serverList struct {
+	lock          sync.Mutex
+	servers       []*server
+	index         int
+	blacklistTime time.Duration
+}
+
+//parseServerAddrs 解析逗号分隔的服务端地址, 返回第一个带有用户名密码的地址中的认证信息
+func parseServerAddrs(addr string) ([]*server, string, string, error) {
+	var username, password string
+	servers := make([]*server, 0)
+	for _, v := range strings.Split(addr, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		u, err := url.Parse(v)
+		if err != nil {
+			return nil, "", "", err
+		}
+		if username == "" && u.User.Username() != "" {
+			if pwd, ok := u.User.Password(); ok {
+				username = u.User.Username()
+				password = pwd
+			}
+		}
+		servers = append(servers, &server{
+			addr:        u.Scheme + "://" + u.Host,
+			contextPath: strings.TrimSuffix(u.Path, "/"),
+		})
+	}
+	if len(servers) == 0 {
+		return nil, "", "", errNoServerAddr
+	}
+	return servers, username, password, nil
+}
+
+func newServerList(servers []*server, blacklistTime time.Duration) *serverList {
+	sl := &serverList{
+		blacklistTime: blacklistTime,
+	}
+	sl.set(servers)
+	return sl
+}
+
+func (c *serverList) set(servers []*server) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.servers = servers
+	c.index = 0
+	if len(servers) > 1 {
+		//随机起点, 避免所有客户端都打到第一个节点
+		c.index = rand.Intn(len(servers))
+	}
+}
+
+//update 更新节点列表, 保留已有节点的黑名单状态, 返回列表是否发生变化
+func (c *serverList) update(servers []*server) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	old := make(map[string]*server)
+	for _, v := range c.servers {
+		old[v.url("")] = v
+	}
+	changed := len(old) != len(servers)
+	for i, v := range servers {
+		if o, ok := old[v.url("")]; ok {
+			servers[i] = o
+		} else {
+			changed = true
+		}
+	}
+	if !changed {
+		return false
+	}
+	var current string
+	if len(c.servers) > 0 {
+		current = c.servers[c.index%len(c.servers)].url("")
+	}
+	c.servers = servers
+	c.index = 0
+	for i, v := range servers {
+		if v.url("") == current {
+			c.index = i
+			break
+		}
+	}
+	return true
+}
+
+//parseEndpointServers 解析地址服务器返回的节点列表, 每行一个 ip:port
+func parseEndpointServers(b []byte, contextPath string) []*server {
+	servers := make([]*server, 0)
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.Contains(line, "://") {
+			if u, err := url.Parse(line); err == nil {
+				servers = append(servers, &server{addr: u.Scheme + "://" + u.Host, contextPath: strings.TrimSuffix(u.Path, "/")})
+			}
+			continue
+		}
+		if !strings.Contains(line, ":") {
+			line += ":" + constant.DefaultServerPort
+		}
+		servers = append(servers, &server{addr: "http://" + line, contextPath: contextPath})
+	}
+	return servers
+}
+
+func (c *serverList) size() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return len(c.servers)
+}
+
+//pick 返回当前可用的节点, 如果都在黑名单中则返回最早解除黑名单的节点
+func (c *serverList) pick() *server {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	l := len(c.servers)
+	if l == 0 {
+		return nil
+	}
+	now := time.Now()
+	var earliest *server
+	for i := 0; i < l; i++ {
+		idx := (c.index + i) % l
+		s := c.servers[idx]
+		if !now.Before(s.failUntil) {
+			c.index = idx
+			return s
+		}
+		if earliest == nil || s.failUntil.Before(earliest.failUntil) {
+			earliest = s
+		}
+	}
+	return earliest
+}
+
+//markFailed 将节点加入黑名单并切换到下一个节点
+func (c *serverList) markFailed(s *server) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	s.failUntil = time.Now().Add(c.blacklistTime)
+	l := len(c.servers)
+	if l > 0 && c.servers[c.index%l] == s {
+		c.index = (c.index + 1) % l
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/patrickmn/go-cache"
@@ -18,9 +19,13 @@ const defaultUDPReadSize int = 4096
 
 type serviceListener struct {
 	port        uint
+	conn        *net.UDPConn
+	done        chan struct{}
+	closeOnce   sync.Once
 	nameSpaceID string
 	services    *cache.Cache
 	callbacks   *cache.Cache
+	snapshot    *namingCache
 	log         LogInterface
 }
 
@@ -77,11 +82,13 @@ func (c *serviceListener) isSubscribed(query *paramMap) bool {
 	return ok
 }
 
-func newServiceListenr(nameSpaceID string, log LogInterface) *serviceListener {
+func newServiceListenr(nameSpaceID string, snapshot *namingCache, log LogInterface) *serviceListener {
 	pr := &serviceListener{
 		nameSpaceID: nameSpaceID,
 		callbacks:   cache.New(5*time.Minute, 10*time.Minute),
 		services:    cache.New(5*time.Minute, 10*time.Minute),
+		done:        make(chan struct{}),
+		snapshot:    snapshot,
 		log:         log,
 	}
 	return pr
@@ -104,19 +111,41 @@ func (c *serviceListener) listen(addr string) error {
 		}
 	}
 	c.port = uint(port)
-	go func() {
-		defer conn.Close()
-		for {
-			c.handleClient(conn)
-		}
-	}()
+	c.conn = conn
 	return nil
 }
 
+//serve 处理服务端的udp推送, 直到 close 被调用
+func (c *serviceListener) serve() {
+	defer c.conn.Close()
+	for {
+		select {
+		case <-c.done:
+			return
+		default:
+		}
+		c.handleClient(c.conn)
+	}
+}
+
+func (c *serviceListener) close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		if c.conn != nil {
+			c.conn.Close()
+		}
+	})
+}
+
 func (c *serviceListener) handleClient(conn *net.UDPConn) {
 	data := make([]byte, defaultUDPReadSize)
 	n, remoteAddr, err := conn.ReadFromUDP(data)
 	if err != nil {
+		select {
+		case <-c.done:
+			return
+		default:
+		}
 		c.log.Error("failed to read UDP msg", err)
 		return
 	}
@@ -138,19 +167,7 @@ func (c *serviceListener) handleClient(conn *net.UDPConn) {
 		if err != nil {
 			c.log.Error("recieve push data error", c.nameSpaceID, err)
 		} else {
-			clusters := make([]string, 0)
-			if service.Clusters != "" {
-				clusters = strings.Split(service.Clusters, ",")
-			}
-			key := c.buildKey(service.Name, clusters)
-			if v, ok := c.services.Get(key); !ok {
-				c.triggerCallback(key, service)
-			} else {
-				sv := v.(*Service)
-				if service.InstanceDiff(sv) {
-					c.triggerCallback(key, service)
-				}
-			}
+			c.onPush(service)
 		}
 		ack["type"] = "push-ack"
 		ack["lastRefTime"] = strconv.FormatInt(pushData.LastRefTime, 10)
@@ -175,6 +192,26 @@ func (c *serviceListener) handleClient(conn *net.UDPConn) {
 	c.log.Debug("push write back", string(bs), remoteAddr)
 }
 
+//onPush 更新推送的服务缓存, 实例有变化时回调订阅者
+func (c *serviceListener) onPush(service *Service) {
+	clusters := make([]string, 0)
+	if service.Clusters != "" {
+		clusters = strings.Split(service.Clusters, ",")
+	}
+	key := c.buildKey(service.Name, clusters)
+	service.LastUpdateTime = time.Now()
+	if v, ok := c.services.Get(key); !ok {
+		c.triggerCallback(key, service)
+	} else {
+		sv := v.(*Service)
+		if service.InstanceDiff(sv) {
+			c.triggerCallback(key, service)
+		}
+	}
+	c.services.Set(key, service, cache.NoExpiration)
+	c.snapshot.save(c.nameSpaceID, key, service)
+}
+
 func (c *serviceListener) triggerCallback(key string, s *Service) {
 	if tmp, ok := c.callbacks.Get(key); ok {
 		fns := tmp.([]*func(*Service))
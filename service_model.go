@@ -98,3 +98,67 @@ func (c *Instance) Diff(a *Instance) bool {
 	}
 	return false
 }
+
+//ServiceList ListServices 返回的一页服务名
+type ServiceList struct {
+	//Count 服务总数
+	Count int `json:"count"`
+	//Services 当前页的服务名, 不带分组
+	Services []string `json:"doms"`
+}
+
+//ServiceSelector 服务的实例选择器, Type 为 none 或 label
+type ServiceSelector struct {
+	Type string `json:"type"`
+	//Expression label选择器的表达式, 如 CONSUMER.label.env = PROVIDER.label.env
+	Expression string `json:"expression,omitempty"`
+}
+
+//ServiceDetail 服务的详细信息(GET /v1/ns/service)
+type ServiceDetail struct {
+	Name             string            `json:"name"`
+	GroupName        string            `json:"groupName"`
+	NamespaceID      string            `json:"namespaceId"`
+	ProtectThreshold float64           `json:"protectThreshold"`
+	Metadata         map[string]string `json:"metadata"`
+	Selector         *ServiceSelector  `json:"selector"`
+	Clusters         []*ClusterDetail  `json:"clusters"`
+}
+
+//Definition 服务详情中可以通过 CreateService/UpdateService 修改的部分
+func (c *ServiceDetail) Definition() ServiceDefinition {
+	return ServiceDefinition{
+		ProtectThreshold: c.ProtectThreshold,
+		Metadata:         c.Metadata,
+		Selector:         c.Selector,
+	}
+}
+
+//ServiceDefinition 创建/修改服务的内容, 修改时整体替换
+type ServiceDefinition struct {
+	//ProtectThreshold 保护阈值(0~1), 健康实例比例低于阈值时返回所有实例
+	ProtectThreshold float64
+	Metadata         map[string]string
+	//Selector nil表示不使用选择器(none)
+	Selector *ServiceSelector
+}
+
+//ClusterDetail 服务下的集群
+type ClusterDetail struct {
+	Name          string            `json:"name"`
+	HealthChecker *HealthChecker    `json:"healthChecker"`
+	Metadata      map[string]string `json:"metadata"`
+}
+
+//InstanceDetail 实例的详细信息(GET /v1/ns/instance)
+type InstanceDetail struct {
+	InstanceId string `json:"instanceId"`
+	Ip         string `json:"ip"`
+	Port       uint64 `json:"port"`
+	//Service 带分组的服务名
+	Service     string            `json:"service"`
+	ClusterName string            `json:"clusterName"`
+	Weight      float64           `json:"weight"`
+	Healthy     bool              `json:"healthy"`
+	Metadata    map[string]string `json:"metadata"`
+}
@@ -0,0 +1,748 @@
+package nacos
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/buger/jsonparser"
+	"github.com/magicdvd/nacos-client/constant"
+)
+
+//Transport 与服务端通信的协议, 默认为http(1.x OpenAPI), EnableGRPC 时为gRPC(2.x长连接)
+//可以通过 WithTransport 替换, 例如测试时使用 NewFakeTransport
+type Transport interface {
+	//Start 客户端创建时调用, push 用于推送服务变更
+	//返回true表示服务变更由服务端推送, 此时临时实例不发送心跳, 订阅不再轮询
+	Start(ctx context.Context, push func(nameSpaceID string, service *Service)) (bool, error)
+	//Login 使用用户名密码登录, 没有配置时直接返回
+	Login(ctx context.Context) error
+	RegisterInstance(ctx context.Context, req *InstanceRequest) error
+	DeregisterInstance(ctx context.Context, req *InstanceRequest) error
+	SendBeat(ctx context.Context, nameSpaceID string, beat *BeatInfo) (*BeatResult, error)
+	QueryInstances(ctx context.Context, query *ServiceQuery) (*Service, error)
+	Subscribe(ctx context.Context, query *ServiceQuery) (*Service, error)
+	Unsubscribe(ctx context.Context, query *ServiceQuery) error
+	//GetConfig 配置不存在时返回 Code 为404, Endpoint 包含 constant.APIConfig 的 *StatusError(errors.Is(err, ErrConfigNotFound))
+	GetConfig(ctx context.Context, req *ConfigRequest) (string, error)
+	//PublishConfig req.CasMD5 不为空时需要做 compare-and-swap, md5不一致时返回 Code 为409的 *StatusError; 不支持时返回 ErrNotSupported
+	PublishConfig(ctx context.Context, req *ConfigRequest) error
+	RemoveConfig(ctx context.Context, req *ConfigRequest) error
+	//ListenConfigs 阻塞直到有配置的md5与服务端不一致或超时, 返回变更的配置
+	ListenConfigs(ctx context.Context, timeout time.Duration, configs []*ConfigListenContext) ([]*ConfigListenContext, error)
+	Close() error
+}
+
+//以下为 Transport 可选实现的接口, 客户端通过类型断言使用, 没有实现时对应的方法返回 ErrNotSupported
+//新增的服务端功能只会添加新的可选接口, 不会修改 Transport
+
+//InstanceUpdater 修改已注册的实例, 用于 UpdateInstance 和 Registration 的修改
+type InstanceUpdater interface {
+	//UpdateInstance 修改已注册实例的权重/metadata/上下线状态, 实例不存在时返回错误
+	UpdateInstance(ctx context.Context, req *InstanceRequest) error
+}
+
+//ClusterUpdater 修改集群, 用于持久化实例的健康检查
+type ClusterUpdater interface {
+	//UpdateCluster 修改集群的健康检查方式, 服务需要已经存在
+	UpdateCluster(ctx context.Context, req *ClusterRequest) error
+}
+
+//ServiceCatalog 服务目录
+type ServiceCatalog interface {
+	//ListServices 分页获取命名空间和分组下的服务名
+	ListServices(ctx context.Context, req *ServiceListRequest) (*ServiceList, error)
+	//GetServiceDetail 服务不存在时返回错误
+	GetServiceDetail(ctx context.Context, query *ServiceQuery) (*ServiceDetail, error)
+	//GetInstanceDetail 只使用 req 中的命名空间/分组/服务名/集群/IP/端口/ephemeral
+	GetInstanceDetail(ctx context.Context, req *InstanceRequest) (*InstanceDetail, error)
+}
+
+//ServiceManager 服务的创建/修改/删除
+type ServiceManager interface {
+	//CreateService 服务已存在时返回错误
+	CreateService(ctx context.Context, req *ServiceRequest) error
+	//UpdateService 服务不存在时返回错误
+	UpdateService(ctx context.Context, req *ServiceRequest) error
+	//DeleteService 服务不存在或还有实例时返回错误
+	DeleteService(ctx context.Context, req *ServiceRequest) error
+}
+
+//NamespaceManager 命名空间管理
+type NamespaceManager interface {
+	ListNamespaces(ctx context.Context) ([]*Namespace, error)
+	//CreateNamespace ID为空时由服务端生成, ID已存在时返回错误
+	CreateNamespace(ctx context.Context, req *NamespaceRequest) error
+	UpdateNamespace(ctx context.Context, req *NamespaceRequest) error
+	DeleteNamespace(ctx context.Context, namespaceID string) error
+}
+
+//ConfigHistoryReader 配置的历史记录
+type ConfigHistoryReader interface {
+	//ListConfigHistory 按时间倒序分页获取配置的历史记录, 不包含内容
+	ListConfigHistory(ctx context.Context, req *ConfigRequest, pageNo int, pageSize int) (*ConfigHistoryPage, error)
+	//GetConfigHistory 获取一条历史记录及其内容, 不存在时返回 Code 为404的 *StatusError
+	GetConfigHistory(ctx context.Context, req *ConfigRequest, nid int64) (*ConfigHistory, error)
+}
+
+//fullTransport 内置的 Transport 实现了所有可选接口
+type fullTransport interface {
+	Transport
+	InstanceUpdater
+	ClusterUpdater
+	ServiceCatalog
+	ServiceManager
+	NamespaceManager
+	ConfigHistoryReader
+}
+
+var (
+	_ fullTransport = (*httpTransport)(nil)
+	_ fullTransport = (*grpcTransport)(nil)
+	_ fullTransport = (*FakeTransport)(nil)
+)
+
+func (c *ServiceClient) instanceUpdater() InstanceUpdater {
+	if t, ok := c.transport.(InstanceUpdater); ok {
+		return t
+	}
+	return unsupportedTransport{}
+}
+
+func (c *ServiceClient) clusterUpdater() ClusterUpdater {
+	if t, ok := c.transport.(ClusterUpdater); ok {
+		return t
+	}
+	return unsupportedTransport{}
+}
+
+func (c *ServiceClient) serviceCatalog() ServiceCatalog {
+	if t, ok := c.transport.(ServiceCatalog); ok {
+		return t
+	}
+	return unsupportedTransport{}
+}
+
+func (c *ServiceClient) serviceManager() ServiceManager {
+	if t, ok := c.transport.(ServiceManager); ok {
+		return t
+	}
+	return unsupportedTransport{}
+}
+
+func (c *ServiceClient) namespaceManager() NamespaceManager {
+	if t, ok := c.transport.(NamespaceManager); ok {
+		return t
+	}
+	return unsupportedTransport{}
+}
+
+func (c *ServiceClient) configHistoryReader() ConfigHistoryReader {
+	if t, ok := c.transport.(ConfigHistoryReader); ok {
+		return t
+	}
+	return unsupportedTransport{}
+}
+
+//unsupportedTransport Transport 没有实现可选接口时使用, 所有方法返回 ErrNotSupported
+type unsupportedTransport struct{}
+
+func (unsupportedTransport) UpdateInstance(ctx context.Context, req *InstanceRequest) error {
+	return ErrNotSupported
+}
+
+func (unsupportedTransport) UpdateCluster(ctx context.Context, req *ClusterRequest) error {
+	return ErrNotSupported
+}
+
+func (unsupportedTransport) ListServices(ctx context.Context, req *ServiceListRequest) (*ServiceList, error) {
+	return nil, ErrNotSupported
+}
+
+func (unsupportedTransport) GetServiceDetail(ctx context.Context, query *ServiceQuery) (*ServiceDetail, error) {
+	return nil, ErrNotSupported
+}
+
+func (unsupportedTransport) GetInstanceDetail(ctx context.Context, req *InstanceRequest) (*InstanceDetail, error) {
+	return nil, ErrNotSupported
+}
+
+func (unsupportedTransport) CreateService(ctx context.Context, req *ServiceRequest) error {
+	return ErrNotSupported
+}
+
+func (unsupportedTransport) UpdateService(ctx context.Context, req *ServiceRequest) error {
+	return ErrNotSupported
+}
+
+func (unsupportedTransport) DeleteService(ctx context.Context, req *ServiceRequest) error {
+	return ErrNotSupported
+}
+
+func (unsupportedTransport) ListNamespaces(ctx context.Context) ([]*Namespace, error) {
+	return nil, ErrNotSupported
+}
+
+func (unsupportedTransport) CreateNamespace(ctx context.Context, req *NamespaceRequest) error {
+	return ErrNotSupported
+}
+
+func (unsupportedTransport) UpdateNamespace(ctx context.Context, req *NamespaceRequest) error {
+	return ErrNotSupported
+}
+
+func (unsupportedTransport) DeleteNamespace(ctx context.Context, namespaceID string) error {
+	return ErrNotSupported
+}
+
+func (unsupportedTransport) ListConfigHistory(ctx context.Context, req *ConfigRequest, pageNo int, pageSize int) (*ConfigHistoryPage, error) {
+	return nil, ErrNotSupported
+}
+
+func (unsupportedTransport) GetConfigHistory(ctx context.Context, req *ConfigRequest, nid int64) (*ConfigHistory, error) {
+	return nil, ErrNotSupported
+}
+
+//InstanceRequest 注册/注销实例, ServiceName 不带分组
+type InstanceRequest struct {
+	NameSpaceID string
+	GroupName   string
+	ServiceName string
+	ClusterName string
+	IP          string
+	Port        uint
+	Weight      float64
+	Enabled     bool
+	Healthy     bool
+	Ephemeral   bool
+	Metadata    map[string]interface{}
+}
+
+//GrouppedServiceName group@@serviceName
+func (c *InstanceRequest) GrouppedServiceName() string {
+	return c.GroupName + "@@" + c.ServiceName
+}
+
+//ClusterRequest 修改集群配置, ServiceName 不带分组
+type ClusterRequest struct {
+	NameSpaceID   string
+	GroupName     string
+	ServiceName   string
+	ClusterName   string
+	HealthChecker *HealthChecker
+}
+
+//GrouppedServiceName group@@serviceName
+func (c *ClusterRequest) GrouppedServiceName() string {
+	return c.GroupName + "@@" + c.ServiceName
+}
+
+//ServiceQuery 查询/订阅服务, UDPPort 大于0时服务端通过udp推送变更(http)
+type ServiceQuery struct {
+	NameSpaceID string
+	GroupName   string
+	ServiceName string
+	Clusters    []string
+	HealthyOnly bool
+	UDPPort     uint
+	ClientIP    string
+	App         string
+}
+
+//GrouppedServiceName group@@serviceName
+func (c *ServiceQuery) GrouppedServiceName() string {
+	return c.GroupName + "@@" + c.ServiceName
+}
+
+//ServiceListRequest 分页获取服务名, PageNo 从1开始
+type ServiceListRequest struct {
+	NameSpaceID string
+	GroupName   string
+	PageNo      int
+	PageSize    int
+	Selector    *ServiceSelector
+}
+
+//ServiceRequest 创建/修改/删除服务, ServiceName 不带分组; 删除时只使用命名空间/分组/服务名
+type ServiceRequest struct {
+	NameSpaceID      string
+	GroupName        string
+	ServiceName      string
+	ProtectThreshold float64
+	Metadata         map[string]string
+	Selector         *ServiceSelector
+}
+
+//GrouppedServiceName group@@serviceName
+func (c *ServiceRequest) GrouppedServiceName() string {
+	return c.GroupName + "@@" + c.ServiceName
+}
+
+//NamespaceRequest 创建/修改命名空间
+type NamespaceRequest struct {
+	ID   string
+	Name string
+	Desc string
+}
+
+//ConfigRequest 获取/发布/删除配置, Content 只在发布时使用
+type ConfigRequest struct {
+	DataID  string
+	Group   string
+	Tenant  string
+	Content string
+	Type    string
+	Tag     string
+	AppName string
+	//CasMD5 发布时服务端当前内容的md5必须与之一致, 否则返回 Code 为409的 *StatusError
+	CasMD5 string
+}
+
+//ConfigListenContext 监听的配置及其当前md5
+type ConfigListenContext struct {
+	DataID string
+	Group  string
+	Tenant string
+	MD5    string
+}
+
+type BeatResult struct {
+	Interval         time.Duration
+	LightBeatEnabled bool
+	//Code 为20404时表示实例已不存在, 需要重新注册
+	Code int64
+}
+
+func newInstanceRequest(query *paramMap) *InstanceRequest {
+	return &InstanceRequest{
+		NameSpaceID: query.nameSpaceID,
+		GroupName:   query.groupName,
+		ServiceName: query.serviceName,
+		ClusterName: query.clusterName,
+		IP:          query.ipAddress,
+		Port:        query.port,
+		Weight:      query.weight,
+		Enabled:     query.enabled,
+		Healthy:     query.healthy,
+		Ephemeral:   query.ephemeral,
+		Metadata:    query.metadata,
+	}
+}
+
+func newServiceQuery(query *paramMap) *ServiceQuery {
+	return &ServiceQuery{
+		NameSpaceID: query.nameSpaceID,
+		GroupName:   query.groupName,
+		ServiceName: query.serviceName,
+		Clusters:    query.clusters,
+		HealthyOnly: query.healthy,
+		UDPPort:     query.udpPort,
+		ClientIP:    query.clientIP,
+		App:         query.app,
+	}
+}
+
+func newConfigRequest(query *paramMap) *ConfigRequest {
+	return &ConfigRequest{
+		DataID:  query.dataID,
+		Group:   query.group,
+		Tenant:  query.tenant,
+		Content: query.content,
+		Type:    query.tp,
+		Tag:     query.tag,
+		AppName: query.appName,
+	}
+}
+
+//httpTransport 1.x OpenAPI
+type httpTransport struct {
+	client       *httpClient
+	versionLock  sync.Mutex
+	casSupported *bool
+}
+
+func (c *httpTransport) Start(ctx context.Context, push func(string, *Service)) (bool, error) {
+	return false, nil
+}
+
+func (c *httpTransport) Login(ctx context.Context) error {
+	if c.client.username == "" {
+		return nil
+	}
+	return c.client.login(ctx)
+}
+
+func instanceParams(req *InstanceRequest) *paramMap {
+	query := newParamMap()
+	query.Set(
+		ParamNameSpaceID(req.NameSpaceID),
+		ParamGroupName(req.GroupName),
+		paramServiceName(req.ServiceName),
+		ParamClusterName(req.ClusterName),
+		paramIPAddress(req.IP),
+		paramPort(req.Port),
+		ParamEphemeral(req.Ephemeral),
+	)
+	return query
+}
+
+func (c *httpTransport) RegisterInstance(ctx context.Context, req *InstanceRequest) error {
+	query := instanceParams(req)
+	query.Set(
+		ParamWeight(req.Weight),
+		ParamEnabled(req.Enabled),
+		ParamHealthy(req.Healthy),
+		ParamMetadata(req.Metadata),
+	)
+	_, err := c.client.api(ctx, http.MethodPost, constant.APIInstance, query, nil)
+	return err
+}
+
+func (c *httpTransport) UpdateInstance(ctx context.Context, req *InstanceRequest) error {
+	query := instanceParams(req)
+	query.Set(
+		ParamWeight(req.Weight),
+		ParamEnabled(req.Enabled),
+		ParamMetadata(req.Metadata),
+	)
+	_, err := c.client.api(ctx, http.MethodPut, constant.APIInstance, query, nil)
+	return err
+}
+
+//UpdateCluster Nacos 要求 serviceName 带分组
+func (c *httpTransport) UpdateCluster(ctx context.Context, req *ClusterRequest) error {
+	query := newParamMap()
+	query.Set(
+		ParamNameSpaceID(req.NameSpaceID),
+		paramServiceName(req.GrouppedServiceName()),
+		ParamClusterName(req.ClusterName),
+		paramHealthChecker(req.HealthChecker),
+	)
+	_, err := c.client.api(ctx, http.MethodPut, constant.APICluster, query, nil)
+	return err
+}
+
+func (c *httpTransport) DeregisterInstance(ctx context.Context, req *InstanceRequest) error {
+	_, err := c.client.api(ctx, http.MethodDelete, constant.APIInstance, instanceParams(req), nil)
+	return err
+}
+
+func (c *httpTransport) SendBeat(ctx context.Context, nameSpaceID string, beat *BeatInfo) (*BeatResult, error) {
+	query := newParamMap()
+	query.Set(ParamNameSpaceID(nameSpaceID), paramServiceName(beat.ServiceName), ParamClusterName(beat.Cluster), paramIPAddress(beat.IP), paramPort(beat.Port))
+	var body *paramMap
+	if !beat.LightBeatEnabled {
+		body = newParamMap()
+		body.Set(paramBeat(beat))
+	}
+	b, err := c.client.api(ctx, http.MethodPut, constant.APIInstanceBeat, query, body)
+	if err != nil {
+		return nil, err
+	}
+	interval, err := jsonparser.GetInt(b, constant.ClientBeatInterval)
+	if err != nil {
+		return nil, err
+	}
+	r := &BeatResult{
+		Interval: time.Duration(interval) * time.Millisecond,
+	}
+	r.LightBeatEnabled, _ = jsonparser.GetBoolean(b, constant.LightBeatEnabled)
+	r.Code, _ = jsonparser.GetInt(b, constant.Code)
+	return r, nil
+}
+
+func (c *httpTransport) QueryInstances(ctx context.Context, q *ServiceQuery) (*Service, error) {
+	query := newParamMap()
+	query.Set(
+		ParamNameSpaceID(q.NameSpaceID),
+		ParamGroupName(q.GroupName),
+		paramServiceName(q.ServiceName),
+		ParamHealthy(q.HealthyOnly),
+	)
+	if len(q.Clusters) > 0 {
+		query.Set(ParamClusters(q.Clusters))
+	}
+	if q.UDPPort > 0 {
+		query.Set(paramUDPPort(q.UDPPort), paramClientIP(q.ClientIP), paramApp(q.App))
+	}
+	b, err := c.client.api(ctx, http.MethodGet, constant.APIInstanceList, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	return parseServiceJSON(b)
+}
+
+func (c *httpTransport) ListServices(ctx context.Context, req *ServiceListRequest) (*ServiceList, error) {
+	query := newParamMap()
+	query.Set(
+		ParamNameSpaceID(req.NameSpaceID),
+		ParamGroupName(req.GroupName),
+		paramPage(req.PageNo, req.PageSize),
+	)
+	if req.Selector != nil {
+		query.Set(ParamServiceSelector(*req.Selector))
+	}
+	b, err := c.client.api(ctx, http.MethodGet, constant.APIServiceList, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	list := new(ServiceList)
+	if err = json.Unmarshal(b, list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (c *httpTransport) GetServiceDetail(ctx context.Context, q *ServiceQuery) (*ServiceDetail, error) {
+	query := serviceParams(&ServiceRequest{NameSpaceID: q.NameSpaceID, GroupName: q.GroupName, ServiceName: q.ServiceName})
+	b, err := c.client.api(ctx, http.MethodGet, constant.APIService, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	detail := new(ServiceDetail)
+	if err = json.Unmarshal(b, detail); err != nil {
+		return nil, err
+	}
+	return detail, nil
+}
+
+func serviceParams(req *ServiceRequest) *paramMap {
+	query := newParamMap()
+	query.Set(
+		ParamNameSpaceID(req.NameSpaceID),
+		ParamGroupName(req.GroupName),
+		paramServiceName(req.ServiceName),
+	)
+	return query
+}
+
+//serviceDefinitionParams metadata 为空时不发送, 服务端同样视为空
+func serviceDefinitionParams(req *ServiceRequest) *paramMap {
+	query := serviceParams(req)
+	metadata := make(map[string]interface{}, len(req.Metadata))
+	for k, v := range req.Metadata {
+		metadata[k] = v
+	}
+	selector := ServiceSelector{Type: "none"}
+	if req.Selector != nil {
+		selector = *req.Selector
+	}
+	query.Set(
+		paramProtectThreshold(req.ProtectThreshold),
+		ParamMetadata(metadata),
+		ParamServiceSelector(selector),
+	)
+	return query
+}
+
+func (c *httpTransport) CreateService(ctx context.Context, req *ServiceRequest) error {
+	_, err := c.client.api(ctx, http.MethodPost, constant.APIService, serviceDefinitionParams(req), nil)
+	return err
+}
+
+func (c *httpTransport) UpdateService(ctx context.Context, req *ServiceRequest) error {
+	_, err := c.client.api(ctx, http.MethodPut, constant.APIService, serviceDefinitionParams(req), nil)
+	return err
+}
+
+func (c *httpTransport) DeleteService(ctx context.Context, req *ServiceRequest) error {
+	_, err := c.client.api(ctx, http.MethodDelete, constant.APIService, serviceParams(req), nil)
+	return err
+}
+
+func (c *httpTransport) GetInstanceDetail(ctx context.Context, req *InstanceRequest) (*InstanceDetail, error) {
+	b, err := c.client.api(ctx, http.MethodGet, constant.APIInstance, instanceParams(req), nil)
+	if err != nil {
+		return nil, err
+	}
+	detail := new(InstanceDetail)
+	if err = json.Unmarshal(b, detail); err != nil {
+		return nil, err
+	}
+	return detail, nil
+}
+
+//Subscribe http下订阅即带上udpPort查询实例列表
+func (c *httpTransport) Subscribe(ctx context.Context, query *ServiceQuery) (*Service, error) {
+	return c.QueryInstances(ctx, query)
+}
+
+func (c *httpTransport) Unsubscribe(ctx context.Context, query *ServiceQuery) error {
+	return nil
+}
+
+//namespaceResult 1.x 控制台接口成功返回true, 失败(如ID已存在)返回false
+func namespaceResult(b []byte, err error) error {
+	if err != nil {
+		return err
+	}
+	if string(b) != "true" {
+		return &StatusError{Code: http.StatusBadRequest, Endpoint: constant.APINamespaces, Body: string(b)}
+	}
+	return nil
+}
+
+func (c *httpTransport) ListNamespaces(ctx context.Context) ([]*Namespace, error) {
+	b, err := c.client.api(ctx, http.MethodGet, constant.APINamespaces, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	namespaces := make([]*Namespace, 0)
+	v, _, _, err := jsonparser.Get(b, "data")
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(v, &namespaces); err != nil {
+		return nil, err
+	}
+	return namespaces, nil
+}
+
+func (c *httpTransport) CreateNamespace(ctx context.Context, req *NamespaceRequest) error {
+	body := newParamMap()
+	body.Set(paramNamespace(req, true))
+	return namespaceResult(c.client.api(ctx, http.MethodPost, constant.APINamespaces, nil, body))
+}
+
+func (c *httpTransport) UpdateNamespace(ctx context.Context, req *NamespaceRequest) error {
+	body := newParamMap()
+	body.Set(paramNamespace(req, false))
+	return namespaceResult(c.client.api(ctx, http.MethodPut, constant.APINamespaces, nil, body))
+}
+
+func (c *httpTransport) DeleteNamespace(ctx context.Context, namespaceID string) error {
+	query := newParamMap()
+	query.Set(ParamNameSpaceID(namespaceID))
+	return namespaceResult(c.client.api(ctx, http.MethodDelete, constant.APINamespaces, query, nil))
+}
+
+func configParams(req *ConfigRequest) *paramMap {
+	query := newParamMap()
+	query.Set(
+		paramConfigDataID(req.DataID),
+		paramConfigGroup(req.Group),
+		ParamConfigTenant(req.Tenant),
+	)
+	if req.Type != "" {
+		query.Set(ParamConfigType(req.Type))
+	}
+	if req.Tag != "" {
+		query.Set(ParamConfigTag(req.Tag))
+	}
+	if req.AppName != "" {
+		query.Set(ParamConfigAppName(req.AppName))
+	}
+	return query
+}
+
+func (c *httpTransport) GetConfig(ctx context.Context, req *ConfigRequest) (string, error) {
+	b, err := c.client.api(ctx, http.MethodGet, constant.APIConfig, configParams(req), nil)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+//PublishConfig Nacos 1.x 会忽略casMd5直接覆盖, 服务端版本低于2.0时 CAS 发布返回 ErrNotSupported
+func (c *httpTransport) PublishConfig(ctx context.Context, req *ConfigRequest) error {
+	body := configParams(req)
+	body.Set(paramConfigContent(req.Content))
+	var headers map[string]string
+	if req.CasMD5 != "" {
+		ok, err := c.supportsCAS(ctx)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrNotSupported
+		}
+		headers = map[string]string{"casMd5": req.CasMD5}
+	}
+	_, err := c.client.apiWithHeaders(ctx, http.MethodPost, constant.APIConfig, headers, nil, body)
+	return err
+}
+
+//supportsCAS 通过 /v1/console/server/state 的版本判断服务端是否支持casMd5, 成功后缓存结果
+//没有该接口的旧版本视为不支持
+func (c *httpTransport) supportsCAS(ctx context.Context) (bool, error) {
+	c.versionLock.Lock()
+	defer c.versionLock.Unlock()
+	if c.casSupported != nil {
+		return *c.casSupported, nil
+	}
+	b, err := c.client.api(ctx, http.MethodGet, constant.APIServerState, nil, nil)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return false, err
+	}
+	supported := false
+	if err == nil {
+		version, _ := jsonparser.GetString(b, "version")
+		major, _ := strconv.Atoi(strings.SplitN(version, ".", 2)[0])
+		supported = major >= 2
+	}
+	c.casSupported = &supported
+	return supported, nil
+}
+
+func (c *httpTransport) RemoveConfig(ctx context.Context, req *ConfigRequest) error {
+	_, err := c.client.api(ctx, http.MethodDelete, constant.APIConfig, configParams(req), nil)
+	return err
+}
+
+func (c *httpTransport) ListConfigHistory(ctx context.Context, req *ConfigRequest, pageNo int, pageSize int) (*ConfigHistoryPage, error) {
+	query := configParams(&ConfigRequest{DataID: req.DataID, Group: req.Group, Tenant: req.Tenant, AppName: req.AppName})
+	query.Set(paramSearch("accurate"), paramPage(pageNo, pageSize))
+	b, err := c.client.api(ctx, http.MethodGet, constant.APIConfigHistory, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	return parseConfigHistoryPage(b)
+}
+
+//GetConfigHistory 2.x 需要同时传入dataId/group/tenant校验
+func (c *httpTransport) GetConfigHistory(ctx context.Context, req *ConfigRequest, nid int64) (*ConfigHistory, error) {
+	query := configParams(&ConfigRequest{DataID: req.DataID, Group: req.Group, Tenant: req.Tenant})
+	query.Set(paramNid(nid))
+	b, err := c.client.api(ctx, http.MethodGet, constant.APIConfigHistory, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	//1.x 历史记录不存在时返回空内容
+	if len(bytes.TrimSpace(b)) == 0 {
+		return nil, &StatusError{Code: http.StatusNotFound, Endpoint: constant.APIConfigHistory, Body: "config history not exist"}
+	}
+	return parseConfigHistory(b)
+}
+
+func (c *httpTransport) ListenConfigs(ctx context.Context, timeout time.Duration, configs []*ConfigListenContext) ([]*ConfigListenContext, error) {
+	var sb strings.Builder
+	for _, v := range configs {
+		sb.WriteString(v.DataID)
+		sb.WriteString(splitChar2)
+		sb.WriteString(v.Group)
+		sb.WriteString(splitChar2)
+		sb.WriteString(v.MD5)
+		if v.Tenant != "" {
+			sb.WriteString(splitChar2)
+			sb.WriteString(v.Tenant)
+		}
+		sb.WriteString(splitChar1)
+	}
+	body := newParamMap()
+	body.Set(paramListenConfigs(sb.String()))
+	b, err := c.client.listen(ctx, http.MethodPost, constant.APIConfigListen, timeout, nil, body)
+	if err != nil {
+		return nil, err
+	}
+	return parseChangedConfigs(b), nil
+}
+
+func (c *httpTransport) Close() error {
+	return nil
+}
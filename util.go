@@ -125,6 +125,11 @@ func getOutboundIP() (string, error) {
 	return "", errors.New("no local IP")
 }
 
+//ConfigMD5 配置内容的md5, 与Nacos计算的一致, 用于 PublishConfigCAS
+func ConfigMD5(content string) string {
+	return md5string(content)
+}
+
 func md5string(content string) (md string) {
 	h := md5.New()
 	_, _ = io.WriteString(h, content)